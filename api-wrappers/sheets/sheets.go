@@ -2,11 +2,11 @@ package sheets
 
 import (
 	"fmt"
-	"log"
-	"math/rand"
-	"time"
+	"log/slog"
+	"strings"
 
 	"github.com/lemonase/youtube-meme-api/client"
+	"github.com/lemonase/youtube-meme-api/status"
 )
 
 /*
@@ -20,123 +20,353 @@ import (
 // Client - The authroized youtube service client (either with a key or a token)
 var Client = &client.Services.Sheets
 
-// SheetID - The main sheet ID that we are working with
-const SheetID = "1MuvC8JpJte1wzAS0m9qR0rr2-gxzL8aaX6lvlKeAqvs"
+// Sheet - A Google Sheet, the ranges the server reads from it and the values fetched from them.
+// The server's own sheet is Main, every tenant has a Sheet of its own
+type Sheet struct {
+	// SheetID - the ID in the sheet's URL
+	SheetID string
+
+	// Ranges
+
+	// VideoRange - Range of values for videos to fetch
+	VideoRange string
+	// PlaylistRange - Range of values for playlists to fetch
+	PlaylistRange string
+	// ChannelRange - Range of values for channels to fetch
+	ChannelRange string
+	// SearchRange - Range of values for searches to fetch
+	SearchRange string
+	// VideoWeightRange - Range of weights for the videos on the same rows
+	VideoWeightRange string
+	// PlaylistWeightRange - Range of weights for the playlists on the same rows
+	PlaylistWeightRange string
+	// ChannelWeightRange - Range of weights for the channels on the same rows
+	ChannelWeightRange string
+	// TagRange - Range of comma separated tags for the video, playlist and channel on the same rows
+	TagRange string
+	// StartRange - Range of clip start times for the videos on the same rows
+	StartRange string
+	// EndRange - Range of clip end times for the videos on the same rows
+	EndRange string
+	// TitleRange - Range of curator titles replacing YouTube's for the videos on the same rows
+	TitleRange string
+	// NoteRange - Range of curator notes replacing YouTube's descriptions for the videos on the same rows
+	NoteRange string
+	// DateRange - Range of original dates replacing YouTube's publish dates for the videos on the same rows
+	DateRange string
+	// CreditRange - Range of credits for the videos on the same rows
+	CreditRange string
+
+	// Values, the weight, tag, clip and override columns are aligned with the rows of the others
+
+	VideoValues          [][]interface{}
+	PlaylistValues       [][]interface{}
+	ChannelValues        [][]interface{}
+	SearchValues         [][]interface{}
+	VideoWeightValues    [][]interface{}
+	PlaylistWeightValues [][]interface{}
+	ChannelWeightValues  [][]interface{}
+	TagValues            [][]interface{}
+	StartValues          [][]interface{}
+	EndValues            [][]interface{}
+	TitleValues          [][]interface{}
+	NoteValues           [][]interface{}
+	DateValues           [][]interface{}
+	CreditValues         [][]interface{}
+
+	// Lengths of the values of the link columns
+
+	VideoLength    int
+	PlaylistLength int
+	ChannelLength  int
+	SearchLength   int
+
+	// Sources - where fetches of the sheet are recorded, nil records nothing
+	Sources *status.Sources
+}
 
-// Ranges
+// Main - The main sheet, the ranges are set from the config
+var Main = &Sheet{
+	SheetID:             "1MuvC8JpJte1wzAS0m9qR0rr2-gxzL8aaX6lvlKeAqvs",
+	VideoRange:          "Sheet1!A2:A1000",
+	VideoWeightRange:    "Sheet1!B2:B1000",
+	PlaylistRange:       "Sheet1!C2:C1000",
+	PlaylistWeightRange: "Sheet1!D2:D1000",
+	ChannelRange:        "Sheet1!E2:E1000",
+	ChannelWeightRange:  "Sheet1!F2:F1000",
+	SearchRange:         "Sheet1!G2:G1000",
+	TagRange:            "Sheet1!H2:H1000",
+	StartRange:          "Sheet1!I2:I1000",
+	EndRange:            "Sheet1!J2:J1000",
+	TitleRange:          "Sheet1!K2:K1000",
+	NoteRange:           "Sheet1!L2:L1000",
+	DateRange:           "Sheet1!M2:M1000",
+	CreditRange:         "Sheet1!N2:N1000",
+	Sources:             status.Main,
+}
 
-// VideoRange - Range of values for videos to fetch
-var VideoRange = "Sheet1!A2:A1000"
+// record - Records the outcome of fetching a range
+func (s *Sheet) record(loaded bool, err error) {
+	if s.Sources != nil {
+		s.Sources.Record("sheet", loaded, err)
+	}
+}
 
-// PlaylistRange - Range of values for playlists to fetch
-var PlaylistRange = "Sheet1!C2:C1000"
+// Fetch Functions
 
-// ChannelRange - Range of values for channels to fetch
-var ChannelRange = "Sheet1!E2:E1000"
+// FetchAllValues - Fetchs all the relevant rows from the Google Sheet,
+// a column that can not be fetched keeps its previous values
+func (s *Sheet) FetchAllValues() error {
+	slog.Info("fetching values from the Google Sheet", "url", "https://docs.google.com/spreadsheets/d/"+s.SheetID)
 
-// SearchRange - Range of values for channels to fetch
-var SearchRange = "Sheet1!G2:G1000"
+	var firstErr error
+	for _, fetch := range []func() error{s.FetchChannelValues, s.FetchPlaylistValues, s.FetchVideoValues} {
+		if err := fetch(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.FetchWeightValues()
+	s.FetchTagValues()
+	s.FetchClipValues()
+	s.FetchOverrideValues()
+	return firstErr
+}
 
-// Values
+// FetchSheetValues - Wrapper to SheetsAPI
+// Params - takes a range of values to get
+// Returns - the length of the values and the values themselves, an empty range
+// (a column the curator cleared) is 0 and nil values without an error
+func (s *Sheet) FetchSheetValues(valueRange string) (int, [][]interface{}, error) {
+	resp, err := Client.Spreadsheets.Values.Get(s.SheetID, valueRange).Context(client.Context()).Do()
+	if err != nil {
+		err = fmt.Errorf("error fetching sheet range %s: %v", valueRange, err)
+		s.record(false, err)
+		return 0, nil, err
+	}
+	s.record(true, nil)
+	if len(resp.Values) == 0 {
+		return 0, nil, nil
+	}
+	return len(resp.Values), resp.Values, nil
+}
 
-// VideoValues - Values for videos that are fetched
-var VideoValues [][]interface{}
+// FetchOptionalSheetValues - Like FetchSheetValues, but an empty range or an error
+// is not fatal since optional columns may not be filled in
+func (s *Sheet) FetchOptionalSheetValues(valueRange string) [][]interface{} {
+	resp, err := Client.Spreadsheets.Values.Get(s.SheetID, valueRange).Context(client.Context()).Do()
+	if err != nil {
+		slog.Warn("could not fetch optional range", "range", valueRange, "err", err)
+		return nil
+	}
+	return resp.Values
+}
 
-// PlaylistValues - Values for playlists that are fetched
-var PlaylistValues [][]interface{}
+// FetchChannelValues - Calls SheetsAPI to retrieve ChannelValues
+func (s *Sheet) FetchChannelValues() error {
+	length, values, err := s.FetchSheetValues(s.ChannelRange)
+	if err != nil {
+		return err
+	}
+	s.ChannelLength, s.ChannelValues = length, values
+	slog.Info("fetched sheet values", "column", "channels", "range", s.ChannelRange, "rows", s.ChannelLength)
+	return nil
+}
 
-// ChannelValues - Values for channels that are fetched
-var ChannelValues [][]interface{}
+// FetchPlaylistValues - Calls SheetsAPI to retrieve PlaylistValues
+func (s *Sheet) FetchPlaylistValues() error {
+	length, values, err := s.FetchSheetValues(s.PlaylistRange)
+	if err != nil {
+		return err
+	}
+	s.PlaylistLength, s.PlaylistValues = length, values
+	slog.Info("fetched sheet values", "column", "playlists", "range", s.PlaylistRange, "rows", s.PlaylistLength)
+	return nil
+}
 
-// SearchValues - Values for searches
-var SearchValues [][]interface{}
+// FetchVideoValues - Calls SheetsAPI to retrieve VideoValues
+func (s *Sheet) FetchVideoValues() error {
+	length, values, err := s.FetchSheetValues(s.VideoRange)
+	if err != nil {
+		return err
+	}
+	s.VideoLength, s.VideoValues = length, values
+	slog.Info("fetched sheet values", "column", "videos", "range", s.VideoRange, "rows", s.VideoLength)
+	return nil
+}
 
-// Lengths
+// FetchSearchValues - Calls SheetsAPI to retrieve SearchValues
+func (s *Sheet) FetchSearchValues() error {
+	length, values, err := s.FetchSheetValues(s.SearchRange)
+	if err != nil {
+		return err
+	}
+	s.SearchLength, s.SearchValues = length, values
+	slog.Info("fetched sheet values", "column", "searches", "range", s.SearchRange, "rows", s.SearchLength)
+	return nil
+}
 
-// ChannelLength - Lengths of channel values
-var ChannelLength int
+// FetchWeightValues - Calls SheetsAPI to retrieve the optional weight columns
+func (s *Sheet) FetchWeightValues() {
+	s.VideoWeightValues = s.FetchOptionalSheetValues(s.VideoWeightRange)
+	s.PlaylistWeightValues = s.FetchOptionalSheetValues(s.PlaylistWeightRange)
+	s.ChannelWeightValues = s.FetchOptionalSheetValues(s.ChannelWeightRange)
+	slog.Info("fetched sheet weights", "ranges", []string{s.VideoWeightRange, s.PlaylistWeightRange, s.ChannelWeightRange})
+}
 
-// PlaylistLength - Length of playlist values
-var PlaylistLength int
+// FetchTagValues - Calls SheetsAPI to retrieve the optional TagValues
+func (s *Sheet) FetchTagValues() {
+	s.TagValues = s.FetchOptionalSheetValues(s.TagRange)
+	slog.Info("fetched sheet tags", "range", s.TagRange, "rows", len(s.TagValues))
+}
 
-// VideoLength - Length of video values
-var VideoLength int
+// FetchClipValues - Calls SheetsAPI to retrieve the optional clip start and end columns
+func (s *Sheet) FetchClipValues() {
+	s.StartValues = s.FetchOptionalSheetValues(s.StartRange)
+	s.EndValues = s.FetchOptionalSheetValues(s.EndRange)
+	slog.Info("fetched sheet clip times", "ranges", []string{s.StartRange, s.EndRange})
+}
 
-// SearcheLength - Length of values for searches
-var SearchLength int
+// FetchOverrideValues - Calls SheetsAPI to retrieve the optional title, note, date and credit columns
+func (s *Sheet) FetchOverrideValues() {
+	s.TitleValues = s.FetchOptionalSheetValues(s.TitleRange)
+	s.NoteValues = s.FetchOptionalSheetValues(s.NoteRange)
+	s.DateValues = s.FetchOptionalSheetValues(s.DateRange)
+	s.CreditValues = s.FetchOptionalSheetValues(s.CreditRange)
+	slog.Info("fetched sheet overrides", "ranges", []string{s.TitleRange, s.NoteRange, s.DateRange, s.CreditRange})
+}
 
-// Fetch Functions
+// CellString - Returns the first cell of a row as a string, or "" if the row is
+// out of range or empty (the API omits trailing empty cells)
+func CellString(values [][]interface{}, row int) string {
+	if row < 0 || row >= len(values) || len(values[row]) < 1 {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", values[row][0]))
+}
 
-// FetchAllValues - Fetchs all the relevant rows from the Google Sheet
-func FetchAllValues() {
-	log.Printf(":: Fetching Values From Google Sheet ::\n")
-	log.Printf("https://docs.google.com/spreadsheets/d/%s\n", SheetID)
+// Cells
 
-	FetchChannelValues()
-	FetchPlaylistValues()
-	FetchVideoValues()
+// Range - A single column range like "Sheet1!A2:A1000"
+type Range struct {
+	Sheet string
+	// Column - 1 for A, 2 for B...
+	Column   int
+	FirstRow int
+	LastRow  int
 }
 
-// FetchSheetValues - Wrapper to SheetsAPI
-// Params - takes a sheetID for a spreadsheet, and a range of values to get
-// Returns - the length of the values and the values themselves
-func FetchSheetValues(sheetID string, playlistRange string) (int, [][]interface{}) {
-	resp, err := Client.Spreadsheets.Values.Get(SheetID, playlistRange).Do()
-	if err != nil {
-		log.Fatalf("Error fetching sheet%v\n", err)
-	} else if len(resp.Values) < 1 {
-		log.Fatalf("No values on sheet %s in range %s!", sheetID, playlistRange)
+// ParseRange - Parses a single column A1 range like "Sheet1!A2:A1000"
+func ParseRange(a1 string) (Range, error) {
+	var r Range
+	bang := strings.LastIndex(a1, "!")
+	if bang < 0 {
+		return r, fmt.Errorf("range %q has no sheet name", a1)
+	}
+	r.Sheet = strings.Trim(a1[:bang], "'")
+	cells := strings.Split(a1[bang+1:], ":")
+	if len(cells) != 2 {
+		return r, fmt.Errorf("range %q is not in the form Sheet!A1:A2", a1)
 	}
-	return len(resp.Values), resp.Values
-}
 
-// FetchChannelValues - Calls SheetsAPI to retrieve ChannelValues
-func FetchChannelValues() {
-	ChannelLength, ChannelValues = FetchSheetValues(SheetID, ChannelRange)
-	log.Printf("	Fetching Channels From Range %s\n", ChannelRange)
-	log.Printf("		Number of Channel URLs: %d\n", ChannelLength)
+	var err error
+	var lastColumn int
+	if r.Column, r.FirstRow, err = parseCell(cells[0]); err != nil {
+		return r, err
+	}
+	if lastColumn, r.LastRow, err = parseCell(cells[1]); err != nil {
+		return r, err
+	}
+	if lastColumn != r.Column {
+		return r, fmt.Errorf("range %q spans more than one column", a1)
+	}
+	return r, nil
 }
 
-// FetchPlaylistValues - Calls SheetsAPI to retrieve PlaylistValues
-func FetchPlaylistValues() {
-	PlaylistLength, PlaylistValues = FetchSheetValues(SheetID, PlaylistRange)
-	log.Printf("	Fetching Playlists From Range %s\n", PlaylistRange)
-	log.Printf("		Number of Playlist URLs: %d\n", PlaylistLength)
+// Cell - Returns the A1 reference of the cell at a row index of the range, like "A12"
+func (r Range) Cell(row int) string {
+	return ColumnName(r.Column) + fmt.Sprint(r.FirstRow+row)
 }
 
-// FetchVideoValues - Calls SheetsAPI to retrieve VideoValues
-func FetchVideoValues() {
-	VideoLength, VideoValues = FetchSheetValues(SheetID, VideoRange)
-	log.Printf("	Fetching Videos From Range %s\n", VideoRange)
-	log.Printf("		Number of Video URLs: %d\n", VideoLength)
+// ColumnName - Returns the letters of a column number, 1 is A and 27 is AA
+func ColumnName(column int) string {
+	name := ""
+	for ; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name
 }
 
-// FetchSearchValues - Calls SheetsAPI to retrieve SearchValues
-func FetchSearchValues() {
-	SearchLength, SearchValues = FetchSheetValues(SheetID, SearchRange)
-	log.Printf("	Fetching Searchs From Range %s\n", SearchRange)
-	log.Printf("		Number of Searches: %d\n", SearchLength)
+// parseCell - Parses a cell reference like "AB12" to a column and row number
+func parseCell(cell string) (column int, row int, err error) {
+	i := 0
+	for ; i < len(cell) && cell[i] >= 'A' && cell[i] <= 'Z'; i++ {
+		column = column*26 + int(cell[i]-'A'+1)
+	}
+	if _, err := fmt.Sscanf(cell[i:], "%d", &row); err != nil || column == 0 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", cell)
+	}
+	return column, row, nil
 }
 
-// Randomizer Functions
+// Column - A column of the sheet the server reads
+type Column struct {
+	// Name - "videos", "videoWeights", "playlists", "playlistWeights", "channels", "channelWeights", "tags",
+	// "starts", "ends", "titles", "notes", "dates" or "credits"
+	Name   string
+	Range  string
+	Values *[][]interface{}
+	Length *int
+}
 
-// GetRandomVideo - Returns a "random" value from all VideoValues
-func GetRandomVideo() string {
-	rand.Seed(time.Now().UnixNano())
-	randIndex := rand.Intn(VideoLength)
-	return fmt.Sprintf("%s", VideoValues[randIndex][0])
+// Columns - Returns the columns of the sheet the server reads
+func (s *Sheet) Columns() []Column {
+	return []Column{
+		{"videos", s.VideoRange, &s.VideoValues, &s.VideoLength},
+		{"videoWeights", s.VideoWeightRange, &s.VideoWeightValues, nil},
+		{"playlists", s.PlaylistRange, &s.PlaylistValues, &s.PlaylistLength},
+		{"playlistWeights", s.PlaylistWeightRange, &s.PlaylistWeightValues, nil},
+		{"channels", s.ChannelRange, &s.ChannelValues, &s.ChannelLength},
+		{"channelWeights", s.ChannelWeightRange, &s.ChannelWeightValues, nil},
+		{"tags", s.TagRange, &s.TagValues, nil},
+		{"starts", s.StartRange, &s.StartValues, nil},
+		{"ends", s.EndRange, &s.EndValues, nil},
+		{"titles", s.TitleRange, &s.TitleValues, nil},
+		{"notes", s.NoteRange, &s.NoteValues, nil},
+		{"dates", s.DateRange, &s.DateValues, nil},
+		{"credits", s.CreditRange, &s.CreditValues, nil},
+	}
 }
 
-// GetRandomPlaylist - Returns a "random" value from all PlaylistValues
-func GetRandomPlaylist() string {
-	rand.Seed(time.Now().UnixNano())
-	randIndex := rand.Intn(PlaylistLength)
-	return fmt.Sprintf("%s", PlaylistValues[randIndex][0])
+// Locate - Returns the column a cell belongs to and the index of its row in the column's values.
+// Only the ranges are read, so it does not have to wait for fetches
+func (s *Sheet) Locate(sheet string, row int, column int) (Column, int, bool) {
+	for _, c := range s.Columns() {
+		r, err := ParseRange(c.Range)
+		if err != nil || r.Sheet != sheet || r.Column != column || row < r.FirstRow || row > r.LastRow {
+			continue
+		}
+		return c, row - r.FirstRow, true
+	}
+	return Column{}, 0, false
 }
 
-// GetRandomChannel - Returns a "random" value from all ChannelValues
-func GetRandomChannel() string {
-	rand.Seed(time.Now().UnixNano())
-	randIndex := rand.Intn(ChannelLength)
-	return fmt.Sprintf("%s", ChannelValues[randIndex][0])
+// SetCell - Sets the cell at a row index of the column's values, growing them if needed.
+// Trailing empty rows are dropped like the Sheets API does
+func (c Column) SetCell(row int, value string) {
+	values := *c.Values
+	for len(values) <= row {
+		values = append(values, []interface{}{})
+	}
+	if value == "" {
+		values[row] = []interface{}{}
+	} else {
+		values[row] = []interface{}{value}
+	}
+	for len(values) > 0 && len(values[len(values)-1]) == 0 {
+		values = values[:len(values)-1]
+	}
+	*c.Values = values
+	if c.Length != nil {
+		*c.Length = len(values)
+	}
 }
@@ -0,0 +1,78 @@
+package sheets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lemonase/youtube-meme-api/client"
+	"google.golang.org/api/option"
+	sheetsapi "google.golang.org/api/sheets/v4"
+)
+
+// fakeSheet - Points the client at a server that answers every range from values by the
+// range's sheet name, a sheet that is not in values fails
+func fakeSheet(t *testing.T, values map[string]string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for sheet, body := range values {
+			if strings.Contains(r.URL.Path, sheet+"!") {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(body))
+				return
+			}
+		}
+		http.Error(w, `{"error": {"code": 500, "message": "backend error"}}`, http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	svc, err := sheetsapi.NewService(context.Background(), option.WithEndpoint(srv.URL), option.WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := client.Services.Sheets
+	client.Services.Sheets = *svc
+	t.Cleanup(func() { client.Services.Sheets = prev })
+}
+
+func TestFetchSheetValues(t *testing.T) {
+	fakeSheet(t, map[string]string{
+		"Filled":  `{"range": "Filled!A2:A3", "values": [["https://youtu.be/abcdefghijk"], ["https://youtu.be/bcdefghijkl"]]}`,
+		"Cleared": `{"range": "Cleared!A2:A1000"}`,
+	})
+
+	tests := []struct {
+		rng     string
+		length  int
+		wantErr bool
+	}{
+		{"Filled!A2:A1000", 2, false},
+		// a cleared column is empty, not an error
+		{"Cleared!A2:A1000", 0, false},
+		{"Broken!A2:A1000", 0, true},
+	}
+	sheet := &Sheet{SheetID: "sheet"}
+	for _, tt := range tests {
+		length, values, err := sheet.FetchSheetValues(tt.rng)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.rng, err, tt.wantErr)
+		}
+		if length != tt.length || len(values) != tt.length {
+			t.Errorf("%s: got %d rows (%d values), want %d", tt.rng, length, len(values), tt.length)
+		}
+	}
+}
+
+func TestFetchVideoValuesCleared(t *testing.T) {
+	fakeSheet(t, map[string]string{"Cleared": `{"range": "Cleared!A2:A1000"}`})
+	sheet := &Sheet{VideoRange: "Cleared!A2:A1000", VideoValues: [][]interface{}{{"https://youtu.be/abcdefghijk"}}, VideoLength: 1}
+
+	if err := sheet.FetchVideoValues(); err != nil {
+		t.Fatalf("FetchVideoValues() = %v", err)
+	}
+	if sheet.VideoLength != 0 || len(sheet.VideoValues) != 0 {
+		t.Errorf("the cleared column kept %d rows", sheet.VideoLength)
+	}
+}
@@ -0,0 +1,297 @@
+package youtube
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/client"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Row updates replace the responses of single sheet cells instead of refetching a whole
+// column. Responses are matched by the ID in the cell's old URL, so they do not have to
+// line up with the sheet rows. Replaced responses go into new slices, since catalog snapshots
+// keep reading the old ones
+
+// SetVideo - Replaces the video of oldURL with the video of newURL. An empty oldURL
+// adds the video, an empty newURL removes it
+func (s *Store) SetVideo(oldURL string, newURL string) error {
+	var res *youtube.VideoListResponse
+	if newURL != "" {
+		id, err := VideoIDFromURL(newURL)
+		if err != nil {
+			return err
+		}
+		if res, err = s.FetchVideo(id); err != nil {
+			return err
+		}
+		if len(res.Items) < 1 {
+			return fmt.Errorf("no video with ID %s", id)
+		}
+	}
+
+	i := -1
+	if oldURL != "" {
+		id, err := VideoIDFromURL(oldURL)
+		if err != nil {
+			return err
+		}
+		if i = s.videoIndex(id); i < 0 {
+			return fmt.Errorf("video %s is not loaded", id)
+		}
+	}
+
+	switch {
+	case i < 0 && res != nil:
+		s.VideoResponses = append(s.VideoResponses, res)
+	case res != nil:
+		s.VideoResponses = append(append(s.VideoResponses[:i:i], res), s.VideoResponses[i+1:]...)
+	case i >= 0:
+		s.VideoResponses = append(s.VideoResponses[:i:i], s.VideoResponses[i+1:]...)
+	}
+	return nil
+}
+
+// videoIndex - Returns the index of a video in VideoResponses or -1
+func (s *Store) videoIndex(id string) int {
+	for i, res := range s.VideoResponses {
+		if len(res.Items) > 0 && res.Items[0].Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetPlaylist - Replaces the playlist of oldURL and its items with the playlist of newURL.
+// An empty oldURL adds the playlist, an empty newURL removes it
+func (s *Store) SetPlaylist(oldURL string, newURL string) error {
+	oldID, newID := "", ""
+	var err error
+	if oldURL != "" {
+		if oldID, err = PlaylistIDFromURL(oldURL); err != nil {
+			return err
+		}
+		if s.playlistIndex(oldID) < 0 {
+			return fmt.Errorf("playlist %s is not loaded", oldID)
+		}
+	}
+	if newURL != "" {
+		if newID, err = PlaylistIDFromURL(newURL); err != nil {
+			return err
+		}
+	}
+	return s.replacePlaylist(oldID, newID)
+}
+
+// replacePlaylist - Replaces a playlist and its items by ID, either ID may be empty
+func (s *Store) replacePlaylist(oldID string, newID string) error {
+	var res *youtube.PlaylistListResponse
+	var items []*youtube.PlaylistItemListResponse
+	if newID != "" {
+		var err error
+		if res, err = s.FetchPlaylist(newID); err != nil {
+			return err
+		}
+		if items, err = s.FetchPlaylistItems(newID); err != nil {
+			return err
+		}
+	}
+
+	i := -1
+	if oldID != "" {
+		i = s.playlistIndex(oldID)
+		var kept []*youtube.PlaylistItemListResponse
+		for _, page := range s.PlaylistItemResponses {
+			if len(page.Items) == 0 || page.Items[0].Snippet == nil || page.Items[0].Snippet.PlaylistId != oldID {
+				kept = append(kept, page)
+			}
+		}
+		s.PlaylistItemResponses = kept
+	}
+
+	switch {
+	case i < 0 && res != nil:
+		s.PlaylistResponses = append(s.PlaylistResponses, res)
+	case res != nil:
+		s.PlaylistResponses = append(append(s.PlaylistResponses[:i:i], res), s.PlaylistResponses[i+1:]...)
+	case i >= 0:
+		s.PlaylistResponses = append(s.PlaylistResponses[:i:i], s.PlaylistResponses[i+1:]...)
+	}
+	s.PlaylistItemResponses = append(s.PlaylistItemResponses, items...)
+	return nil
+}
+
+// playlistIndex - Returns the index of a playlist in PlaylistResponses or -1
+func (s *Store) playlistIndex(id string) int {
+	for i, res := range s.PlaylistResponses {
+		if len(res.Items) > 0 && res.Items[0].Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetChannel - Replaces the channel of oldURL and its uploads playlist with the channel of
+// newURL. An empty oldURL adds the channel, an empty newURL removes it
+func (s *Store) SetChannel(oldURL string, newURL string) error {
+	var res *youtube.ChannelListResponse
+	if newURL != "" {
+		id, err := ChannelIDFromURL(newURL)
+		if err != nil {
+			return err
+		}
+		if res, err = s.FetchChannel(id); err != nil {
+			return err
+		}
+	}
+
+	i := -1
+	if oldURL != "" {
+		id, err := ChannelIDFromURL(oldURL)
+		if err != nil {
+			return err
+		}
+		if i = s.channelIndex(id); i < 0 {
+			return fmt.Errorf("channel %s is not loaded", id)
+		}
+	}
+
+	oldUploads, newUploads := "", ""
+	if i >= 0 {
+		oldUploads = uploadsOf(s.ChannelResponses[i])
+	}
+	if res != nil {
+		newUploads = uploadsOf(res)
+	}
+	if err := s.replacePlaylist(oldUploads, newUploads); err != nil {
+		return err
+	}
+
+	switch {
+	case i < 0 && res != nil:
+		s.ChannelResponses = append(s.ChannelResponses, res)
+	case res != nil:
+		s.ChannelResponses = append(append(s.ChannelResponses[:i:i], res), s.ChannelResponses[i+1:]...)
+	case i >= 0:
+		s.ChannelResponses = append(s.ChannelResponses[:i:i], s.ChannelResponses[i+1:]...)
+	}
+	return nil
+}
+
+// channelIndex - Returns the index of a channel in ChannelResponses by ID or custom URL, or -1
+func (s *Store) channelIndex(id string) int {
+	for i, res := range s.ChannelResponses {
+		if len(res.Items) == 0 {
+			continue
+		}
+		c := res.Items[0]
+		if c.Id == id || (c.Snippet != nil && strings.EqualFold(strings.TrimPrefix(c.Snippet.CustomUrl, "@"), id)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ChannelUploads - Returns the uploads playlist ID of the loaded channel with an ID or custom URL,
+// or "" if the channel is not loaded
+func (s *Store) ChannelUploads(id string) string {
+	if i := s.channelIndex(id); i >= 0 {
+		return uploadsOf(s.ChannelResponses[i])
+	}
+	return ""
+}
+
+// uploadsOf - Returns the ID of a channel's uploads playlist
+func uploadsOf(res *youtube.ChannelListResponse) string {
+	if len(res.Items) == 0 || res.Items[0].ContentDetails == nil || res.Items[0].ContentDetails.RelatedPlaylists == nil {
+		return ""
+	}
+	return res.Items[0].ContentDetails.RelatedPlaylists.Uploads
+}
+
+// RefetchChannels - Refetches the channels of the sheet and replaces the loaded ones, their
+// uploads playlists and the items of those. Reports whether the channels were replaced, the
+// loaded responses are kept if no channel could be fetched or the fetch was cancelled
+func (s *Store) RefetchChannels() (bool, error) {
+	oldChannels, oldPlaylists, oldItems := s.ChannelResponses, s.PlaylistResponses, s.PlaylistItemResponses
+	restore := func() {
+		s.ChannelResponses, s.PlaylistResponses, s.PlaylistItemResponses = oldChannels, oldPlaylists, oldItems
+	}
+
+	uploads := map[string]bool{}
+	for _, res := range s.ChannelResponses {
+		if id := uploadsOf(res); id != "" {
+			uploads[id] = true
+		}
+	}
+	var playlists []*youtube.PlaylistListResponse
+	for _, res := range s.PlaylistResponses {
+		if len(res.Items) == 0 || !uploads[res.Items[0].Id] {
+			playlists = append(playlists, res)
+		}
+	}
+	var items []*youtube.PlaylistItemListResponse
+	for _, page := range s.PlaylistItemResponses {
+		if len(page.Items) == 0 || page.Items[0].Snippet == nil || !uploads[page.Items[0].Snippet.PlaylistId] {
+			items = append(items, page)
+		}
+	}
+	s.ChannelResponses, s.PlaylistResponses, s.PlaylistItemResponses = nil, playlists, items
+
+	var errs FetchErrors
+	if err := s.FetchOrRead("channel", true); err != nil {
+		if len(s.ChannelResponses) == 0 || Canceled() {
+			restore()
+			return false, err
+		}
+		errs = append(errs, err)
+	}
+	for _, res := range s.ChannelResponses {
+		id := uploadsOf(res)
+		if id == "" {
+			continue
+		}
+		pages, err := s.FetchPlaylistItems(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.PlaylistItemResponses = append(s.PlaylistItemResponses, pages...)
+	}
+	if Canceled() {
+		restore()
+		return false, client.Context().Err()
+	}
+	if err := s.SaveAll(); err != nil {
+		errs = append(errs, err)
+	}
+	return true, errs.orNil()
+}
+
+// SaveAll - Writes every response type to its JSON file
+func (s *Store) SaveAll() error {
+	if err := checkAndCreateDir(s.DataDirectory); err != nil {
+		return err
+	}
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{s.channelJSONFile(), s.ChannelResponses},
+		{s.playlistJSONFile(), s.PlaylistResponses},
+		{s.playlistItemJSONFile(), s.PlaylistItemResponses},
+		{s.videoJSONFile(), s.VideoResponses},
+	}
+	for _, f := range files {
+		j, err := json.Marshal(f.v)
+		if err != nil {
+			return fmt.Errorf("error marshalling %s: %v", f.name, err)
+		}
+		if err := ioutil.WriteFile(f.name, j, 0755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	"github.com/lemonase/youtube-meme-api/client"
+	"github.com/lemonase/youtube-meme-api/metrics"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+// fakeYouTube - Points the client at a server that knows every channel, each with an uploads
+// playlist "UU" + channel ID of one video
+func fakeYouTube(t *testing.T) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res interface{}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/channels"):
+			res = youtube.ChannelListResponse{Items: []*youtube.Channel{{
+				Id:             q.Get("id"),
+				ContentDetails: &youtube.ChannelContentDetails{RelatedPlaylists: &youtube.ChannelContentDetailsRelatedPlaylists{Uploads: "UU" + q.Get("id")}},
+			}}}
+		case strings.HasSuffix(r.URL.Path, "/playlists"):
+			res = youtube.PlaylistListResponse{Items: []*youtube.Playlist{{Id: q.Get("id")}}}
+		case strings.HasSuffix(r.URL.Path, "/playlistItems"):
+			res = youtube.PlaylistItemListResponse{Items: []*youtube.PlaylistItem{{
+				Snippet: &youtube.PlaylistItemSnippet{PlaylistId: q.Get("playlistId")},
+			}}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+
+	httpClient := srv.Client()
+	httpClient.Transport = metrics.Transport(httpClient.Transport)
+	svc, err := youtube.NewService(context.Background(), option.WithEndpoint(srv.URL), option.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := client.Services.YouTube
+	client.Services.YouTube = *svc
+	t.Cleanup(func() { client.Services.YouTube = prev })
+}
+
+func TestRefetchChannels(t *testing.T) {
+	fakeYouTube(t)
+	sheet := &sheets.Sheet{ChannelValues: [][]interface{}{
+		{"https://www.youtube.com/channel/UCa"}, {"https://www.youtube.com/channel/UCb"},
+	}}
+	// a playlist from the sheet that has to survive the refreshes
+	store := &Store{
+		DataDirectory:         t.TempDir(),
+		Sheet:                 sheet,
+		PlaylistResponses:     []*youtube.PlaylistListResponse{{Items: []*youtube.Playlist{{Id: "PLsheet"}}}},
+		PlaylistItemResponses: []*youtube.PlaylistItemListResponse{{Items: []*youtube.PlaylistItem{{Snippet: &youtube.PlaylistItemSnippet{PlaylistId: "PLsheet"}}}}},
+	}
+
+	for i := 0; i < 2; i++ {
+		if replaced, err := store.RefetchChannels(); !replaced || err != nil {
+			t.Fatalf("refresh %d: RefetchChannels() = %v, %v", i, replaced, err)
+		}
+	}
+
+	count := func(ids []string) map[string]int {
+		n := map[string]int{}
+		for _, id := range ids {
+			n[id]++
+		}
+		return n
+	}
+	var playlists, items []string
+	for _, res := range store.PlaylistResponses {
+		playlists = append(playlists, res.Items[0].Id)
+	}
+	for _, page := range store.PlaylistItemResponses {
+		items = append(items, page.Items[0].Snippet.PlaylistId)
+	}
+	want := map[string]int{"PLsheet": 1, "UUUCa": 1, "UUUCb": 1}
+	for name, got := range map[string]map[string]int{"playlists": count(playlists), "item pages": count(items)} {
+		if len(got) != len(want) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+		for id, n := range want {
+			if got[id] != n {
+				t.Errorf("%s: got %v, want %v", name, got, want)
+				break
+			}
+		}
+	}
+	if len(store.ChannelResponses) != 2 {
+		t.Errorf("got %d channels, want 2", len(store.ChannelResponses))
+	}
+	// a channel, an uploads playlist and a page of its items for each channel and refresh
+	if used := store.QuotaUsed(); used != 12 {
+		t.Errorf("QuotaUsed() = %d, want 12", used)
+	}
+}
@@ -1,11 +1,11 @@
 package youtube
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
-	"math/rand"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,6 +13,8 @@ import (
 
 	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
 	"github.com/lemonase/youtube-meme-api/client"
+	"github.com/lemonase/youtube-meme-api/metrics"
+	"github.com/lemonase/youtube-meme-api/status"
 	"google.golang.org/api/youtube/v3"
 )
 
@@ -30,31 +32,83 @@ var Client = &client.Services.YouTube
 // PageSize - the number of items that will be returned in a single API call
 var PageSize int64 = 50
 
-// The base directory where JSON responses are stored
-var dataDirectory = "data"
+// Store - The YouTube responses of the links on a sheet and the directory they are saved in.
+// The main catalog's are in Main, every tenant has a Store of its own
+type Store struct {
+	// DataDirectory - The base directory where JSON responses are stored
+	DataDirectory string
+	// Sheet - the sheet whose links are fetched
+	Sheet *sheets.Sheet
+	// Sources - where fetches are recorded, nil records nothing
+	Sources *status.Sources
+
+	// Response Data
+
+	// VideoResponses - holds responses from videos
+	VideoResponses []*youtube.VideoListResponse
+	// PlaylistResponses - holds responses from playlists
+	PlaylistResponses []*youtube.PlaylistListResponse
+	// PlaylistItemResponses - holds responses for items of a playlist
+	PlaylistItemResponses []*youtube.PlaylistItemListResponse
+	// ChannelResponses - holds responses from channels
+	ChannelResponses []*youtube.ChannelListResponse
+	// SearchResponses - holds response from a search call
+	SearchResponses []*youtube.SearchListResponse
+
+	// quota - the quota units used by the store's API calls
+	quota metrics.QuotaMeter
+}
+
+// Main - The responses of the main sheet
+var Main = &Store{DataDirectory: "data", Sheet: sheets.Main, Sources: status.Main}
+
+// QuotaUsed - Returns the YouTube API quota units the store's fetches used since the start
+func (s *Store) QuotaUsed() int64 {
+	return s.quota.Used()
+}
+
+// context - Returns the context the store's API calls are made with, cancelled on shutdown
+// and counting their quota
+func (s *Store) context() context.Context {
+	return metrics.WithQuotaMeter(client.Context(), &s.quota)
+}
 
-// Response Data
+// record - Records the outcome of loading a page type
+func (s *Store) record(pageType string, loaded bool, err error) {
+	if s.Sources != nil {
+		s.Sources.Record(pageType, loaded, err)
+	}
+}
 
-// VideoResponses - holds responses from videos
-var VideoResponses []*youtube.VideoListResponse
-var videoJSONFile = filepath.Join(dataDirectory, "video.json")
+// Files in DataDirectory the responses are stored in
 
-// PlaylistResponses - holds responses from playlists
-var PlaylistResponses []*youtube.PlaylistListResponse
-var playlistJSONFile = filepath.Join(dataDirectory, "playlist.json")
+func (s *Store) videoJSONFile() string    { return filepath.Join(s.DataDirectory, "video.json") }
+func (s *Store) playlistJSONFile() string { return filepath.Join(s.DataDirectory, "playlist.json") }
+func (s *Store) playlistItemJSONFile() string {
+	return filepath.Join(s.DataDirectory, "playlist_item.json")
+}
+func (s *Store) channelJSONFile() string { return filepath.Join(s.DataDirectory, "channel.json") }
+func (s *Store) searchJSONFile() string  { return filepath.Join(s.DataDirectory, "search.json") }
 
-// PlaylistItemResponses - holds responses for items of a playlist
-var PlaylistItemResponses []*youtube.PlaylistItemListResponse
-var playlistItemJSONFile = filepath.Join(dataDirectory, "playlist_item.json")
-var playlistItemCount int
+var refreshDuration = metrics.NewHistogramVec("refresh_duration_seconds", "Time taken to read or fetch the responses of a type",
+	[]float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}, "type")
 
-// ChannelResponses - holds responses from channels
-var ChannelResponses []*youtube.ChannelListResponse
-var channelJSONFile = filepath.Join(dataDirectory, "channel.json")
+var refreshFailures = metrics.NewCounterVec("refresh_failures_total", "Reads or fetches of a response type with items that could not be loaded", "type")
 
-// SearchResponses - holds response from a search call
-var SearchResponses []*youtube.SearchListResponse
-var searchJSONFile = filepath.Join(dataDirectory, "search.json")
+// CacheFiles - Returns the file each page type is stored in
+func (s *Store) CacheFiles() map[string]string {
+	return CacheFilesIn(s.DataDirectory)
+}
+
+// CacheFilesIn - Returns the file each page type is stored in for a data directory
+func CacheFilesIn(dir string) map[string]string {
+	return map[string]string{
+		"channel":      filepath.Join(dir, "channel.json"),
+		"playlist":     filepath.Join(dir, "playlist.json"),
+		"playlistItem": filepath.Join(dir, "playlist_item.json"),
+		"video":        filepath.Join(dir, "video.json"),
+	}
+}
 
 // Files
 
@@ -63,285 +117,404 @@ func fileExists(filename string) bool {
 	if os.IsNotExist(err) {
 		return false
 	} else if err != nil {
-		log.Printf("Error stating file %s", filename)
+		slog.Warn("could not stat file", "file", filename, "err", err)
 		return false
 	}
 	return !info.IsDir()
 }
 
-func checkAndCreateDir(directory string) {
+func checkAndCreateDir(directory string) error {
 	_, err := os.Stat(directory)
 	if os.IsNotExist(err) {
 		errDir := os.MkdirAll(directory, 0755)
 		if errDir != nil {
-			log.Fatal(errDir)
+			return errDir
 		}
 	}
+	return nil
 }
 
 // Fetching
 
-// FetchOrRead - Read or fetch and write all values for a specific page type
-func FetchOrRead(pageType string, forceRefresh bool) {
-	checkAndCreateDir(dataDirectory)
+// FetchOrRead - Read or fetch and write all values for a specific page type.
+// Items that could not be fetched are skipped and reported in the returned error,
+// the file is only written if something was fetched
+func (s *Store) FetchOrRead(pageType string, forceRefresh bool) (err error) {
+	start := time.Now()
+	loaded := false
+	defer func() {
+		if _, ok := s.CacheFiles()[pageType]; ok {
+			s.record(pageType, loaded, err)
+			refreshDuration.Observe(time.Since(start).Seconds(), pageType)
+			if err != nil {
+				refreshFailures.Inc(pageType)
+			}
+		}
+	}()
+
+	if err := checkAndCreateDir(s.DataDirectory); err != nil {
+		return err
+	}
+	var fetchErr error
 	if pageType == "channel" {
-		if fileExists(channelJSONFile) && !forceRefresh {
-			log.Printf("	Fetching Channel Info From %s", channelJSONFile)
-			data, err := ioutil.ReadFile(channelJSONFile)
+		if fileExists(s.channelJSONFile()) && !forceRefresh {
+			slog.Info("reading responses", "type", pageType, "file", s.channelJSONFile())
+			data, err := ioutil.ReadFile(s.channelJSONFile())
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
-			err = json.Unmarshal(data, &ChannelResponses)
+			err = json.Unmarshal(data, &s.ChannelResponses)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		} else {
-			log.Printf("	Fetching Channel Info From YouTube API\n")
-			FetchAllType("channels")
-			j, err := json.Marshal(ChannelResponses)
+			slog.Info("fetching responses from the YouTube API", "type", pageType)
+			fetchErr = s.FetchAllType("channels")
+			if fetchErr != nil && (len(s.ChannelResponses) == 0 || Canceled()) {
+				return fetchErr
+			}
+			j, err := json.Marshal(s.ChannelResponses)
 			if err != nil {
-				log.Fatalf("Error marshalling json")
+				return fmt.Errorf("error marshalling json: %v", err)
 			}
-			err = ioutil.WriteFile(channelJSONFile, j, 0755)
+			err = ioutil.WriteFile(s.channelJSONFile(), j, 0755)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		}
-		log.Printf("		Number of Channel Playlists: %d\n", len(ChannelResponses))
+		loaded = true
+		slog.Info("loaded responses", "type", pageType, "responses", len(s.ChannelResponses))
 
 	} else if pageType == "playlist" {
-		if fileExists(playlistJSONFile) && !forceRefresh {
-			log.Printf("	Fetching Playlist Info From %s\n", playlistJSONFile)
-			data, err := ioutil.ReadFile(playlistJSONFile)
+		if fileExists(s.playlistJSONFile()) && !forceRefresh {
+			slog.Info("reading responses", "type", pageType, "file", s.playlistJSONFile())
+			data, err := ioutil.ReadFile(s.playlistJSONFile())
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
-			err = json.Unmarshal(data, &PlaylistResponses)
+			err = json.Unmarshal(data, &s.PlaylistResponses)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		} else {
-			log.Printf("	Fetching Playlist Info From YouTube API\n")
-			FetchAllType("playlists")
-			j, err := json.Marshal(PlaylistResponses)
+			slog.Info("fetching responses from the YouTube API", "type", pageType)
+			fetchErr = s.FetchAllType("playlists")
+			if fetchErr != nil && (len(s.PlaylistResponses) == 0 || Canceled()) {
+				return fetchErr
+			}
+			j, err := json.Marshal(s.PlaylistResponses)
 			if err != nil {
-				log.Fatalf("Error marshalling json")
+				return fmt.Errorf("error marshalling json: %v", err)
 			}
-			err = ioutil.WriteFile(playlistJSONFile, j, 0755)
+			err = ioutil.WriteFile(s.playlistJSONFile(), j, 0755)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		}
-		log.Printf("		Number of Playlists: %d\n", len(PlaylistResponses))
+		loaded = true
+		slog.Info("loaded responses", "type", pageType, "responses", len(s.PlaylistResponses))
 
 	} else if pageType == "playlistItem" {
-		if fileExists(playlistItemJSONFile) && !forceRefresh {
-			log.Printf("	Fetching Playlist Items From %s\n", playlistItemJSONFile)
-			data, err := ioutil.ReadFile(playlistItemJSONFile)
+		if fileExists(s.playlistItemJSONFile()) && !forceRefresh {
+			slog.Info("reading responses", "type", pageType, "file", s.playlistItemJSONFile())
+			data, err := ioutil.ReadFile(s.playlistItemJSONFile())
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
-			err = json.Unmarshal(data, &PlaylistItemResponses)
+			err = json.Unmarshal(data, &s.PlaylistItemResponses)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		} else {
-			log.Printf("	Fetching Playlist Items From YouTube API\n")
-			FetchAllType("playlistItems")
-			j, err := json.Marshal(PlaylistItemResponses)
+			slog.Info("fetching responses from the YouTube API", "type", pageType)
+			fetchErr = s.FetchAllType("playlistItems")
+			if fetchErr != nil && (len(s.PlaylistItemResponses) == 0 || Canceled()) {
+				return fetchErr
+			}
+			j, err := json.Marshal(s.PlaylistItemResponses)
 			if err != nil {
-				log.Fatalf("Error marshalling json")
+				return fmt.Errorf("error marshalling json: %v", err)
 			}
-			err = ioutil.WriteFile(playlistItemJSONFile, j, 0755)
+			err = ioutil.WriteFile(s.playlistItemJSONFile(), j, 0755)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		}
-		log.Printf("		Number of Playlist Pages: %d\n", len(PlaylistItemResponses))
-		for _, pl := range PlaylistItemResponses {
-			playlistItemCount += len(pl.Items)
-		}
-		log.Printf("		Number of Playlist Items: %d\n", playlistItemCount)
+		loaded = true
+		slog.Info("loaded responses", "type", pageType, "responses", len(s.PlaylistItemResponses), "items", len(s.AllPlaylistItems()))
 
 	} else if pageType == "video" {
-		if fileExists(videoJSONFile) && !forceRefresh {
-			log.Printf("	Fetching Videos From %s\n", videoJSONFile)
-			data, err := ioutil.ReadFile(videoJSONFile)
+		if fileExists(s.videoJSONFile()) && !forceRefresh {
+			slog.Info("reading responses", "type", pageType, "file", s.videoJSONFile())
+			data, err := ioutil.ReadFile(s.videoJSONFile())
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
-			err = json.Unmarshal(data, &VideoResponses)
+			err = json.Unmarshal(data, &s.VideoResponses)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		} else {
-			log.Printf("	Fetching Videos From YouTube API\n")
-			FetchAllType("videos")
-			j, err := json.Marshal(VideoResponses)
+			slog.Info("fetching responses from the YouTube API", "type", pageType)
+			fetchErr = s.FetchAllType("videos")
+			if fetchErr != nil && (len(s.VideoResponses) == 0 || Canceled()) {
+				return fetchErr
+			}
+			j, err := json.Marshal(s.VideoResponses)
 			if err != nil {
-				log.Fatalf("Error marshalling json")
+				return fmt.Errorf("error marshalling json: %v", err)
 			}
-			err = ioutil.WriteFile(videoJSONFile, j, 0755)
+			err = ioutil.WriteFile(s.videoJSONFile(), j, 0755)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		}
-		log.Printf("		Number of Videos: %d\n", len(VideoResponses))
+		loaded = true
+		slog.Info("loaded responses", "type", pageType, "responses", len(s.VideoResponses))
 
 	} else {
-		log.Fatalf("Unknown page type to fetch\n")
+		return fmt.Errorf("unknown page type %q", pageType)
 	}
+	return fetchErr
 }
 
-// FetchOrReadAll - Fetches all content types from the Youtube API
-func FetchOrReadAll(forceRefresh bool) {
-	log.Println(":: Fetching All YouTube Data ::")
+// ReadCached - Reads the responses saved by earlier fetches without calling the API,
+// types without a file are left as they are
+func (s *Store) ReadCached() error {
+	targets := map[string]interface{}{
+		"channel":      &s.ChannelResponses,
+		"playlist":     &s.PlaylistResponses,
+		"playlistItem": &s.PlaylistItemResponses,
+		"video":        &s.VideoResponses,
+	}
+	var errs FetchErrors
+	for pageType, file := range s.CacheFiles() {
+		if !fileExists(file) {
+			continue
+		}
+		data, err := ioutil.ReadFile(file)
+		if err == nil {
+			err = json.Unmarshal(data, targets[pageType])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error reading %s: %v", file, err))
+			continue
+		}
+		slog.Info("read cached responses", "type", pageType, "file", file)
+	}
+	return errs.orNil()
+}
+
+// FetchOrReadAll - Fetches all content types from the Youtube API, carrying on
+// past errors and returning them together
+func (s *Store) FetchOrReadAll(forceRefresh bool) error {
+	slog.Info("fetching all YouTube data", "forceRefresh", forceRefresh)
+	var errs FetchErrors
 	contentTypes := []string{"channel", "playlist", "playlistItem", "video"}
 	for _, contentType := range contentTypes {
-		FetchOrRead(contentType, forceRefresh)
+		if err := s.FetchOrRead(contentType, forceRefresh); err != nil {
+			slog.Error("could not fetch YouTube data", "type", contentType, "err", err)
+			errs = append(errs, err)
+		}
+	}
+	return errs.orNil()
+}
+
+// FetchErrors - Errors of items that could not be fetched, the others were fetched
+type FetchErrors []error
+
+func (e FetchErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// orNil - Returns nil instead of an empty list, so callers can compare with nil
+func (e FetchErrors) orNil() error {
+	if len(e) == 0 {
+		return nil
 	}
+	return e
 }
 
-// FetchAllType - Fetches responses for all of a given type
-func FetchAllType(contentType string) {
+// FetchAllType - Fetches responses for all of a given type, skipping (and returning)
+// the sheet rows that can not be fetched
+func (s *Store) FetchAllType(contentType string) error {
+	var errs FetchErrors
 	switch contentType {
-	case "channel":
-		for _, url := range sheets.ChannelValues {
-			channelURL := string(url[0].(string))
-			ChannelResponses = append(ChannelResponses, GetChannelResponseFromURL(channelURL))
+	case "channels":
+		var fetched []*youtube.ChannelListResponse
+		for i := range s.Sheet.ChannelValues {
+			channelURL := sheets.CellString(s.Sheet.ChannelValues, i)
+			if channelURL == "" {
+				continue
+			}
+			id, err := ChannelIDFromURL(channelURL)
+			var res *youtube.ChannelListResponse
+			if err == nil {
+				res, err = s.FetchChannel(id)
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			fetched = append(fetched, res)
 		}
-		for _, channelRes := range ChannelResponses {
-			uploadPl := GetPlaylistResponseFromID(channelRes.Items[0].ContentDetails.RelatedPlaylists.Uploads)
-			PlaylistResponses = append(PlaylistResponses, uploadPl)
+		s.ChannelResponses = append(s.ChannelResponses, fetched...)
+		for _, channelRes := range fetched {
+			uploads := uploadsOf(channelRes)
+			if uploads == "" {
+				continue
+			}
+			uploadPl, err := s.FetchPlaylist(uploads)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			s.PlaylistResponses = append(s.PlaylistResponses, uploadPl)
 		}
-	case "playlist":
-		for _, url := range sheets.PlaylistValues {
-			playlistURL := string(url[0].(string))
-			PlaylistResponses = append(PlaylistResponses, GetPlaylistRepsonseFromURL(playlistURL))
+	case "playlists":
+		for i := range s.Sheet.PlaylistValues {
+			playlistURL := sheets.CellString(s.Sheet.PlaylistValues, i)
+			if playlistURL == "" {
+				continue
+			}
+			id, err := PlaylistIDFromURL(playlistURL)
+			var res *youtube.PlaylistListResponse
+			if err == nil {
+				res, err = s.FetchPlaylist(id)
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			s.PlaylistResponses = append(s.PlaylistResponses, res)
 		}
 	case "playlistItems":
-		for _, pl := range PlaylistResponses {
-			PlaylistItemResponses = append(PlaylistItemResponses, GetAllPlaylistItemResponsesFromPlaylistID(pl.Items[0].Id)...)
-			playlistItemCount += len(pl.Items)
+		for _, pl := range s.PlaylistResponses {
+			if len(pl.Items) == 0 {
+				continue
+			}
+			pages, err := s.FetchPlaylistItems(pl.Items[0].Id)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			s.PlaylistItemResponses = append(s.PlaylistItemResponses, pages...)
 		}
 	case "videos":
-		for _, url := range sheets.VideoValues {
-			videoURL := string(url[0].(string))
-			VideoResponses = append(VideoResponses, GetVideoResponseFromURL(videoURL))
+		for i := range s.Sheet.VideoValues {
+			videoURL := sheets.CellString(s.Sheet.VideoValues, i)
+			if videoURL == "" {
+				continue
+			}
+			id, err := VideoIDFromURL(videoURL)
+			var res *youtube.VideoListResponse
+			if err == nil {
+				res, err = s.FetchVideo(id)
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			s.VideoResponses = append(s.VideoResponses, res)
 		}
 	default:
-		log.Fatalf("Unknown content type for FetchAllType\n")
+		return fmt.Errorf("unknown content type %q", contentType)
 	}
+	// the rows after the cancellation failed without being fetched
+	if err := client.Context().Err(); err != nil {
+		return err
+	}
+	return errs.orNil()
 }
 
-// Randomizers
-
-// GetRandomVideo - Returns a random video response
-func GetRandomVideo() *youtube.VideoListResponse {
-	rand.Seed(time.Now().UnixNano())
-	randIndex := int64(rand.Intn(len(VideoResponses)))
-	return VideoResponses[randIndex]
-}
-
-// GetRandomPlaylist - Returns a random playlist response
-func GetRandomPlaylist() *youtube.PlaylistListResponse {
-	rand.Seed(time.Now().UnixNano())
-	randIndex := int64(rand.Intn(len(PlaylistResponses)))
-	return PlaylistResponses[randIndex]
+// Canceled - Reports whether fetches were cancelled for shutdown. Cancelled fetches are incomplete,
+// so callers should keep the previous responses
+func Canceled() bool {
+	return client.Context().Err() != nil
 }
 
-// GetRandomPlaylistItem - Returns a random playlist video response
-func GetRandomPlaylistItem() *youtube.PlaylistItem {
-	// randomPlaylist := GetRandomPlaylist()
-	// id := randomPlaylist.Items[0].Id
-	// videoCount := randomPlaylist.Items[0].ContentDetails.ItemCount
-	rand.Seed(time.Now().UnixNano())
-	randIndex := int64(rand.Intn(int(len(PlaylistItemResponses))))
-
-	randPl := PlaylistItemResponses[randIndex]
-	item := randPl.Items[rand.Intn(len(randPl.Items))]
+// Playlist Items
 
-	return item
-}
-
-// GetRandomChannel - Returns a random playlist channel response
-func GetRandomChannel() *youtube.ChannelListResponse {
-	rand.Seed(time.Now().UnixNano())
-	randIndex := int64(rand.Intn(len(ChannelResponses)))
-	return ChannelResponses[randIndex]
+// AllPlaylistItems - Returns the items of every page in PlaylistItemResponses as one list
+func (s *Store) AllPlaylistItems() []*youtube.PlaylistItem {
+	var items []*youtube.PlaylistItem
+	for _, page := range s.PlaylistItemResponses {
+		items = append(items, page.Items...)
+	}
+	return items
 }
 
 // Video Utils
 
-// GetVideoIDFromURL - Get the video id from a given url
-func GetVideoIDFromURL(url string) string {
-	param := "v="
-	if strings.Contains(url, param) {
-		return url[strings.LastIndex(url, param)+len(param):]
+// VideoIDFromURL - Get the video id from a given url, a watch or youtu.be link.
+// Parameters after the id (like t= for the start time) are cut off
+func VideoIDFromURL(url string) (string, error) {
+	for _, param := range []string{"v=", "youtu.be/"} {
+		if strings.Contains(url, param) {
+			id := url[strings.LastIndex(url, param)+len(param):]
+			if end := strings.IndexAny(id, "&?#/"); end >= 0 {
+				id = id[:end]
+			}
+			if id != "" {
+				return id, nil
+			}
+		}
 	}
-	log.Fatalf("Could not retrive Video ID from URL: %s", url)
-	return ""
+	return "", fmt.Errorf("could not retrieve video ID from URL: %s", url)
 }
 
-// GetVideoResponseFromID - Returns a video response from video ID
-func GetVideoResponseFromID(id string) *youtube.VideoListResponse {
+// FetchVideo - Returns a video response from video ID
+func (s *Store) FetchVideo(id string) (*youtube.VideoListResponse, error) {
 	part := []string{"snippet,contentDetails"}
 
 	Call := Client.Videos.List(part)
 	Call = Call.Id(id)
 
-	res, err := Call.Do()
+	res, err := Call.Context(s.context()).Do()
 	if err != nil {
-		log.Fatalf("Error fetching youtube video %v\n", err)
+		return nil, fmt.Errorf("error fetching youtube video %s: %v", id, err)
 	}
 
-	return res
-}
-
-// GetVideoResponseFromURL - Returns a video response from a video URL
-func GetVideoResponseFromURL(url string) *youtube.VideoListResponse {
-	return GetVideoResponseFromID(GetVideoIDFromURL(url))
+	return res, nil
 }
 
 // Playlist Utils
 
-// GetPlaylistIDFromURL - Takes a URL string and gets everything to the right of playlist param
-func GetPlaylistIDFromURL(url string) string {
+// PlaylistIDFromURL - Takes a URL string and gets everything to the right of playlist param
+func PlaylistIDFromURL(url string) (string, error) {
 	possibleParams := []string{"list=", "p="}
 	for _, p := range possibleParams {
 		if strings.Contains(url, p) {
-			return url[strings.LastIndex(url, p)+len(p):]
+			return url[strings.LastIndex(url, p)+len(p):], nil
 		}
 	}
-	log.Fatalf("Could not retrieve Playlist ID from URL: %s", url)
-	return ""
+	return "", fmt.Errorf("could not retrieve playlist ID from URL: %s", url)
 }
 
-// GetPlaylistResponseFromID - Takes a playlist id and executes API call to playlists service
-func GetPlaylistResponseFromID(id string) *youtube.PlaylistListResponse {
+// FetchPlaylist - Takes a playlist id and executes API call to playlists service
+func (s *Store) FetchPlaylist(id string) (*youtube.PlaylistListResponse, error) {
 	part := []string{"snippet,contentDetails"}
 
 	Call := Client.Playlists.List(part)
 	Call = Call.Id(id)
 
-	res, err := Call.Do()
+	res, err := Call.Context(s.context()).Do()
 	if err != nil {
-		log.Fatalf("Error fetching playlist %v\n", err)
+		return nil, fmt.Errorf("error fetching playlist %s: %v", id, err)
 	}
 	if len(res.Items) < 1 {
-		log.Fatalf("No items in playlist %s\n", id)
+		return nil, fmt.Errorf("no items in playlist %s", id)
 	}
 
-	return res
-}
-
-// GetPlaylistRepsonseFromURL - Takes a URL string and returns an playlist response
-func GetPlaylistRepsonseFromURL(url string) *youtube.PlaylistListResponse {
-	return GetPlaylistResponseFromID(GetPlaylistIDFromURL(url))
+	return res, nil
 }
 
 // GetAllVideoItemsFromPlaylistID - Retruns a list of videos from playlist
-func GetAllVideoItemsFromPlaylistID(id string) []*youtube.VideoListResponse {
+func (s *Store) GetAllVideoItemsFromPlaylistID(id string) ([]*youtube.VideoListResponse, error) {
 	var playlistVideos []*youtube.VideoListResponse
 
 	part := []string{"contentDetails"}
@@ -350,60 +523,66 @@ func GetAllVideoItemsFromPlaylistID(id string) []*youtube.VideoListResponse {
 	Call = Call.PlaylistId(id)
 	Call = Call.MaxResults(PageSize)
 
-	res, err := Call.Do()
+	res, err := Call.Context(s.context()).Do()
 	if err != nil {
-		log.Fatalf("Error fetching playlist %v\n", err)
+		return nil, fmt.Errorf("error fetching playlist %s: %v", id, err)
 	}
 	if len(res.Items) < 1 {
-		log.Fatalf("No items in playlist %s\n", id)
+		return nil, fmt.Errorf("no items in playlist %s", id)
 	}
 
 	for pageIndex := int64(0); pageIndex <= int64(len(res.Items)); pageIndex += PageSize {
-		res, err := Call.Do()
+		res, err := Call.Context(s.context()).Do()
 		if err != nil {
-			log.Fatalf("Error fetching playlist %v\n", err)
+			return nil, fmt.Errorf("error fetching playlist %s: %v", id, err)
 		}
 		for _, item := range res.Items {
-			playlistVideos = append(playlistVideos, GetVideoResponseFromID(item.ContentDetails.VideoId))
+			video, err := s.FetchVideo(item.ContentDetails.VideoId)
+			if err != nil {
+				return nil, err
+			}
+			playlistVideos = append(playlistVideos, video)
 		}
 
 		Call.PageToken(res.NextPageToken)
 	}
 
-	return playlistVideos
+	return playlistVideos, nil
 }
 
-// GetAllPlaylistItemResponsesFromPlaylistID - Appends all playlist item responses from an ID to the main list
-func GetAllPlaylistItemResponsesFromPlaylistID(id string) []*youtube.PlaylistItemListResponse {
+// FetchPlaylistItems - Returns every page of playlist item responses for a playlist ID
+func (s *Store) FetchPlaylistItems(id string) ([]*youtube.PlaylistItemListResponse, error) {
 	var playlistItemResponses []*youtube.PlaylistItemListResponse
 
-	part := []string{"contentDetails"}
+	part := []string{"snippet,contentDetails"}
 	Call := Client.PlaylistItems.List(part)
 
 	Call = Call.PlaylistId(id)
 	Call = Call.MaxResults(PageSize)
 
-	res, err := Call.Do()
-	if err != nil {
-		log.Fatalf("Error fetching playlist %v\n", err)
-	}
-	if len(res.Items) < 1 {
-		log.Fatalf("No items in playlist %s\n", id)
-	}
-
-	for pageIndex := int64(0); pageIndex <= int64(len(res.Items)); pageIndex += PageSize {
-		res, err := Call.Do()
+	// pagination occurs in the API with tokens, so we follow
+	// the next page token until there are no pages left
+	for {
+		res, err := Call.Context(s.context()).Do()
 		if err != nil {
-			log.Fatalf("Error fetching playlist %v\n", err)
+			return nil, fmt.Errorf("error fetching items of playlist %s: %v", id, err)
+		}
+		if len(playlistItemResponses) == 0 && len(res.Items) < 1 {
+			return nil, fmt.Errorf("no items in playlist %s", id)
 		}
 		playlistItemResponses = append(playlistItemResponses, res)
+
+		if res.NextPageToken == "" {
+			break
+		}
+		Call = Call.PageToken(res.NextPageToken)
 	}
 
-	return playlistItemResponses
+	return playlistItemResponses, nil
 }
 
 // GetPlaylistItemsResponseFromIDAtIndex - Takes an id and position of a video in a playlist and returns a response
-func GetPlaylistItemsResponseFromIDAtIndex(id string, videoIndex int64) *youtube.PlaylistItemListResponse {
+func (s *Store) GetPlaylistItemsResponseFromIDAtIndex(id string, videoIndex int64) (*youtube.PlaylistItemListResponse, error) {
 	var correctPageRes *youtube.PlaylistItemListResponse
 
 	part := []string{"contentDetails"}
@@ -415,86 +594,88 @@ func GetPlaylistItemsResponseFromIDAtIndex(id string, videoIndex int64) *youtube
 	// pagination occurs in the API with tokens, so we iterate through
 	// pages until the index of the requested video is within the page
 	for pageIndex := int64(0); pageIndex <= videoIndex; pageIndex += PageSize {
-		res, err := Call.Do()
+		res, err := Call.Context(s.context()).Do()
 		if err != nil {
-			log.Fatalf("Error fetching playlist %v\n", err)
+			return nil, fmt.Errorf("error fetching playlist %s: %v", id, err)
 		}
 		correctPageRes = res
 		Call.PageToken(res.NextPageToken)
 	}
 
-	if len(correctPageRes.Items) < 1 {
-		log.Fatalf("No items returned in response: Check playlist https:/www.yotube.com/playlist?list=%v at index %v", id, videoIndex)
+	if correctPageRes == nil || len(correctPageRes.Items) < 1 {
+		return nil, fmt.Errorf("no items returned in response: check playlist https://www.youtube.com/playlist?list=%v at index %v", id, videoIndex)
 	}
 
-	return correctPageRes
+	return correctPageRes, nil
 }
 
 // GetPlaylistItemsResponseFromURLAtIndex - Takes a URL string and index, returns playlist items response
-func GetPlaylistItemsResponseFromURLAtIndex(url string, videoIndex int64) *youtube.PlaylistItemListResponse {
-	return GetPlaylistItemsResponseFromIDAtIndex(GetPlaylistIDFromURL(url), videoIndex)
+func (s *Store) GetPlaylistItemsResponseFromURLAtIndex(url string, videoIndex int64) (*youtube.PlaylistItemListResponse, error) {
+	id, err := PlaylistIDFromURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPlaylistItemsResponseFromIDAtIndex(id, videoIndex)
 }
 
 // Channels
 
-// GetChannelIDFromURL - Takes a string, splits it on "/" and gets the last field
-func GetChannelIDFromURL(url string) string {
+// ChannelIDFromURL - Takes a string, splits it on "/" and gets the last field
+func ChannelIDFromURL(url string) (string, error) {
 	paramList := []string{"/channel/", "/c/", "/user/"}
 	for _, p := range paramList {
 		if strings.Contains(url, p) {
 			slice := strings.Split(url, "/")
-			return string(slice[len(slice)-1:][0])
+			return string(slice[len(slice)-1:][0]), nil
 		}
 	}
-	log.Fatalf("Could not retrieve Channel ID from URL: %s", url)
-	return ""
+	return "", fmt.Errorf("could not retrieve channel ID from URL: %s", url)
 }
 
-// GetChannelResponseFromID - Returns a channel response given an ID
-func GetChannelResponseFromID(id string) *youtube.ChannelListResponse {
+// FetchChannel - Returns a channel response given an ID (or a username)
+func (s *Store) FetchChannel(id string) (*youtube.ChannelListResponse, error) {
 	part := []string{"snippet,contentDetails"}
 
 	Call := Client.Channels.List(part)
 	Call.MaxResults(PageSize)
 	Call.Id(id)
 
-	res, err := Call.Do()
+	res, err := Call.Context(s.context()).Do()
 	if err != nil {
-		log.Fatalf("Error fetching channel details %v\n", err)
+		return nil, fmt.Errorf("error fetching channel details %s: %v", id, err)
 	}
 	if len(res.Items) < 1 {
 		newCall := Client.Channels.List(part)
 		newCall.ForUsername(id)
-		newRes, err := newCall.Do()
+		newRes, err := newCall.Context(s.context()).Do()
 		if err != nil {
-			log.Fatalf("Error fetching channel details %v\n", err)
+			return nil, fmt.Errorf("error fetching channel details %s: %v", id, err)
 		}
 		if len(newRes.Items) < 1 {
-			log.Fatalf("No items in channel response for username: %s", id)
+			return nil, fmt.Errorf("no items in channel response for username: %s", id)
 		}
 
-		return newRes
+		return newRes, nil
 	}
 
-	return res
-}
-
-// GetChannelResponseFromURL - Returns a channel response from a URL
-func GetChannelResponseFromURL(url string) *youtube.ChannelListResponse {
-	return GetChannelResponseFromID(GetChannelIDFromURL(url))
+	return res, nil
 }
 
 // ChannelsListByUsername - example function from docs
-func ChannelsListByUsername(username string) {
-	call := Client.Channels.List(strings.Split("snippet,contentDetails", ","))
+func (s *Store) ChannelsListByUsername(username string) error {
+	call := Client.Channels.List(strings.Split("snippet,contentDetails,statistics", ","))
 	call = call.ForUsername(username)
-	response, err := call.Do()
+	response, err := call.Context(s.context()).Do()
 	if err != nil {
-		log.Fatalf("Error calling API: %v\n", err.Error())
+		return fmt.Errorf("error calling API: %v", err)
+	}
+	if len(response.Items) < 1 {
+		return fmt.Errorf("no channel for username %s", username)
 	}
 	fmt.Println(fmt.Sprintf("This channel's ID is %s. Its title is '%s', "+
 		"and it has %d views.",
 		response.Items[0].Id,
 		response.Items[0].Snippet.Title,
 		response.Items[0].Statistics.ViewCount))
+	return nil
 }
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"strings"
+)
+
+// OpenAPI - Returns an OpenAPI 3 document describing the routes
+func OpenAPI(title string, version string, description string, routes []Route) Schema {
+	g := &schemaGenerator{components: make(map[string]Schema)}
+	paths := Schema{}
+
+	for _, r := range routes {
+		op := Schema{
+			"summary":     r.Summary,
+			"operationId": operationID(r),
+			"tags":        []string{r.Group},
+		}
+
+		var params []Schema
+		for _, p := range r.Params {
+			typ := p.Type
+			if typ == "" {
+				typ = "string"
+			}
+			schema := Schema{"type": typ}
+			if len(p.Enum) > 0 {
+				schema["enum"] = p.Enum
+			}
+			params = append(params, Schema{
+				"name":        p.Name,
+				"in":          p.In,
+				"description": p.Description,
+				// path parameters are always required
+				"required": p.Required || p.In == "path",
+				"schema":   schema,
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if body := g.schemaOf(r.Request); body != nil {
+			op["requestBody"] = Schema{
+				"required": true,
+				"content":  Schema{"application/json": Schema{"schema": body}},
+			}
+		}
+
+		media := Schema{}
+		if schema := g.schemaOf(r.Response); schema != nil {
+			media["schema"] = schema
+		}
+		responses := Schema{
+			"200": Schema{
+				"description": "OK",
+				"content":     Schema{r.ResponseContentType(): media},
+			},
+		}
+		if r.Scope != "" {
+			op["security"] = []Schema{{"bearerToken": []string{}}, {"signedRequest": []string{}}}
+			op["description"] = "Requires an admin token with the `" + r.Scope + "` scope."
+			responses["401"] = Schema{"description": "Missing or invalid credentials"}
+			responses["403"] = Schema{"description": "The token does not have the " + r.Scope + " scope"}
+		}
+		op["responses"] = responses
+
+		item, ok := paths[r.Path].(Schema)
+		if !ok {
+			item = Schema{}
+			paths[r.Path] = item
+		}
+		item[strings.ToLower(r.Method)] = op
+	}
+
+	return Schema{
+		"openapi": "3.0.3",
+		"info": Schema{
+			"title":       title,
+			"version":     version,
+			"description": description,
+		},
+		"paths": paths,
+		"components": Schema{
+			"schemas":         g.components,
+			"securitySchemes": securitySchemes,
+		},
+	}
+}
+
+// securitySchemes - The ways admin routes can be authenticated, see the auth package
+var securitySchemes = Schema{
+	"bearerToken": Schema{
+		"type":        "http",
+		"scheme":      "bearer",
+		"description": "The secret of an admin token",
+	},
+	"signedRequest": Schema{
+		"type": "apiKey",
+		"in":   "header",
+		"name": "X-Auth-Key",
+		"description": "The name of an admin token, sent with X-Auth-Timestamp (unix seconds) and X-Auth-Signature: " +
+			"the hex HMAC-SHA256 with the token secret of the method, request URI, timestamp and hex SHA-256 of the body joined by newlines",
+	},
+}
+
+// operationID - Returns a unique id for a route made from its method and path
+func operationID(r Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(r.Method))
+	upper := true
+	for _, c := range r.Path {
+		switch {
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9':
+			if upper && c >= 'a' && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			b.WriteRune(c)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	return b.String()
+}
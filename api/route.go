@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Param - A query or path parameter of a route
+type Param struct {
+	Name string
+	// In - "query" or "path"
+	In          string
+	Description string
+	// Type - "string", "integer" or "boolean"
+	Type     string
+	Required bool
+	// Enum - the allowed values, if limited
+	Enum []string
+}
+
+// Route - An endpoint of the server, used to register handlers, generate the
+// OpenAPI document and list endpoints in help messages
+type Route struct {
+	Method string
+	// Path - the documented path, with {name} for path parameters
+	Path string
+	// Pattern - the ServeMux pattern that serves the path, defaults to Path
+	Pattern string
+	// Group - the heading the route is listed under
+	Group   string
+	Summary string
+	Params  []Param
+	// Request - a value of the request body type, nil if there is no body
+	Request interface{}
+	// Response - a value of the response body type, nil if the response is not JSON
+	Response interface{}
+	// ContentType - the response content type, defaults to application/json
+	ContentType string
+	// Scope - the admin token scope the route requires, empty for public routes
+	Scope   string
+	Handler http.HandlerFunc
+}
+
+// MuxPattern - Returns the pattern the route is registered with
+func (r Route) MuxPattern() string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.Path
+}
+
+// ResponseContentType - Returns the content type of successful responses
+func (r Route) ResponseContentType() string {
+	if r.ContentType != "" {
+		return r.ContentType
+	}
+	return "application/json"
+}
+
+// Groups - Returns the routes grouped by Group, in the order the groups first appear
+func Groups(routes []Route) (names []string, groups map[string][]Route) {
+	groups = make(map[string][]Route)
+	for _, r := range routes {
+		if _, ok := groups[r.Group]; !ok {
+			names = append(names, r.Group)
+		}
+		groups[r.Group] = append(groups[r.Group], r)
+	}
+	return names, groups
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema - A JSON schema object of an OpenAPI document
+type Schema map[string]interface{}
+
+// schemaGenerator - Builds schemas from Go types, named struct types become components
+type schemaGenerator struct {
+	components map[string]Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// componentName - Returns a name for a struct type that is unique across packages
+func componentName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	// versioned packages like youtube/v3 are named after their parent
+	if len(pkg) > 1 && pkg[0] == 'v' && strings.Trim(pkg[1:], "0123456789") == "" {
+		parts := strings.Split(t.PkgPath(), "/")
+		if len(parts) > 1 {
+			pkg = parts[len(parts)-2]
+		}
+	}
+	return pkg + "." + t.Name()
+}
+
+// schemaOf - Returns the schema of a value's type, or nil for a nil value
+func (g *schemaGenerator) schemaOf(v interface{}) Schema {
+	if v == nil {
+		return nil
+	}
+	return g.schema(reflect.TypeOf(v))
+}
+
+// schema - Returns the schema of a type
+func (g *schemaGenerator) schema(t reflect.Type) Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return Schema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Schema{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return Schema{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := componentName(t)
+		if _, ok := g.components[name]; !ok {
+			// reserve the name first so recursive types refer back to it
+			g.components[name] = Schema{}
+			g.components[name] = g.structSchema(t)
+		}
+		return Schema{"$ref": "#/components/schemas/" + name}
+	default:
+		// interfaces can hold anything
+		return Schema{}
+	}
+}
+
+// structSchema - Returns an object schema with a property per JSON field
+func (g *schemaGenerator) structSchema(t reflect.Type) Schema {
+	properties := Schema{}
+	g.addFields(t, properties)
+	return Schema{"type": "object", "properties": properties}
+}
+
+// addFields - Adds the JSON fields of a struct to properties, including embedded ones
+func (g *schemaGenerator) addFields(t reflect.Type, properties Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(ft, properties)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		// the ",string" option encodes numbers as strings
+		if strings.Contains(","+opts+",", ",string,") {
+			properties[name] = Schema{"type": "string"}
+			continue
+		}
+		properties[name] = g.schema(f.Type)
+	}
+}
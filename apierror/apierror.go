@@ -0,0 +1,111 @@
+package apierror
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/requestid"
+)
+
+// Error - The JSON body of every error response
+type Error struct {
+	// Code - a short machine readable name for the status, e.g. "not_found"
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// RequestID - the X-Request-ID of the request, to find it in the logs
+	RequestID string `json:"requestId,omitempty"`
+	// Details - more information about the error, e.g. the invalid parameter
+	Details interface{} `json:"details,omitempty"`
+}
+
+// CodeFor - Returns the snake case name of an HTTP status, e.g. 404 is "not_found"
+func CodeFor(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.ReplaceAll(text, "-", " ")), " ", "_")
+}
+
+// Write - Writes an error response with a JSON body
+func Write(w http.ResponseWriter, r *http.Request, status int, message string) {
+	WriteDetails(w, r, status, message, nil)
+}
+
+// WriteDetails - Writes an error response with a JSON body that includes details
+func WriteDetails(w http.ResponseWriter, r *http.Request, status int, message string, details interface{}) {
+	body := Error{Code: CodeFor(status), Message: message, Details: details}
+	if r != nil {
+		body.RequestID = requestid.Get(r)
+	}
+	j, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		slog.Error("could not encode error details", "err", err)
+		body.Details = nil
+		j, _ = json.MarshalIndent(body, "", "  ")
+	}
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(j)
+	w.Write([]byte("\n"))
+}
+
+// Param - Details naming the invalid query or path parameter
+func Param(name string) map[string]string {
+	return map[string]string{"parameter": name}
+}
+
+// recorder - Remembers whether the response has started
+type recorder struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *recorder) WriteHeader(status int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recorder) Write(p []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *recorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.started = true
+		f.Flush()
+	}
+}
+
+// Recover - Turns panics in handlers into 500 responses instead of dropped connections.
+// If the response already started it can only be cut short
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &recorder{ResponseWriter: w}
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+			slog.ErrorContext(r.Context(), "panic serving request", "method", r.Method, "path", r.URL.Path, "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+			if rec.started {
+				panic(http.ErrAbortHandler)
+			}
+			Write(w, r, http.StatusInternalServerError, "Internal server error")
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/apierror"
+)
+
+// Scope - A permission that a token can be given
+type Scope string
+
+const (
+	// ScopeRefresh - may refetch data from the sheet and YouTube
+	ScopeRefresh Scope = "refresh"
+	// ScopeModerate - may inspect and manage the sheet and its tenants
+	ScopeModerate Scope = "moderate"
+	// ScopeReadStats - may read server statistics
+	ScopeReadStats Scope = "read-stats"
+	// ScopeAll - every scope
+	ScopeAll Scope = "*"
+)
+
+// Scopes - Every scope a token can be given
+var Scopes = []Scope{ScopeRefresh, ScopeModerate, ScopeReadStats}
+
+// Signed request headers
+const (
+	KeyHeader       = "X-Auth-Key"
+	TimestampHeader = "X-Auth-Timestamp"
+	SignatureHeader = "X-Auth-Signature"
+)
+
+// MaxClockSkew - How far a signed request's timestamp may be from the server's clock
+var MaxClockSkew = 5 * time.Minute
+
+// maxSignedBody - The largest request body that is read to check a signature
+const maxSignedBody = 1 << 20
+
+// Token - A named secret and the scopes it grants
+type Token struct {
+	Name   string
+	Secret string
+	Scopes []Scope
+}
+
+// Has - Reports whether the token grants the scope
+func (t Token) Has(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == ScopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu     sync.RWMutex
+	tokens []Token
+)
+
+// ParseTokens - Parses comma separated tokens in the form name:secret:scope+scope,
+// e.g. "deploy:s3cret:refresh,mods:hunter2:moderate+read-stats" ("*" grants every scope)
+func ParseTokens(spec string) ([]Token, error) {
+	var parsed []Token
+	names := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.SplitN(part, ":", 3)
+		if len(fields) != 3 || fields[0] == "" || fields[1] == "" || fields[2] == "" {
+			return nil, fmt.Errorf("admin token %q is not in the form name:secret:scope+scope", fields[0])
+		}
+		if names[fields[0]] {
+			return nil, fmt.Errorf("admin token name %q is used twice", fields[0])
+		}
+		names[fields[0]] = true
+
+		t := Token{Name: fields[0], Secret: fields[1]}
+		for _, s := range strings.Split(fields[2], "+") {
+			scope := Scope(s)
+			if !validScope(scope) {
+				return nil, fmt.Errorf("admin token %q has unknown scope %q", t.Name, s)
+			}
+			t.Scopes = append(t.Scopes, scope)
+		}
+		parsed = append(parsed, t)
+	}
+	return parsed, nil
+}
+
+func validScope(scope Scope) bool {
+	if scope == ScopeAll {
+		return true
+	}
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTokens - Replaces the accepted tokens
+func SetTokens(t []Token) {
+	mu.Lock()
+	defer mu.Unlock()
+	tokens = t
+}
+
+// Tokens - Returns the accepted tokens
+func Tokens() []Token {
+	mu.RLock()
+	defer mu.RUnlock()
+	return tokens
+}
+
+// byName - Returns the token with a name
+func byName(name string) (Token, bool) {
+	for _, t := range Tokens() {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Token{}, false
+}
+
+// bySecret - Returns the token with a secret, comparing every token in constant time
+func bySecret(secret string) (Token, bool) {
+	var found Token
+	ok := false
+	for _, t := range Tokens() {
+		if subtle.ConstantTimeCompare([]byte(t.Secret), []byte(secret)) == 1 {
+			found, ok = t, true
+		}
+	}
+	return found, ok
+}
+
+// Sign - Returns the hex HMAC-SHA256 of a request:
+// method, request URI, unix timestamp and the hex SHA-256 of the body, joined by newlines
+func Sign(secret string, method string, requestURI string, timestamp int64, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%s", method, requestURI, timestamp, hex.EncodeToString(bodyHash[:]))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckTimestamp - Returns an error if a unix timestamp is missing or too far from now
+func CheckTimestamp(s string, now time.Time) error {
+	ts, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("timestamp must be unix seconds")
+	}
+	skew := now.Sub(time.Unix(ts, 0))
+	if skew > MaxClockSkew || skew < -MaxClockSkew {
+		return fmt.Errorf("timestamp is more than %s from the server time", MaxClockSkew)
+	}
+	return nil
+}
+
+// ReplayGuard - Remembers signatures until they expire so a signed request
+// can not be sent twice within the clock skew window
+type ReplayGuard struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewReplayGuard - Returns an empty guard
+func NewReplayGuard() *ReplayGuard {
+	return &ReplayGuard{seen: make(map[string]time.Time)}
+}
+
+// Check - Returns false if the signature was already seen, otherwise remembers it
+func (g *ReplayGuard) Check(signature string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for sig, expires := range g.seen {
+		if now.After(expires) {
+			delete(g.seen, sig)
+		}
+	}
+	if _, ok := g.seen[signature]; ok {
+		return false
+	}
+	g.seen[signature] = now.Add(2 * MaxClockSkew)
+	return true
+}
+
+var replays = NewReplayGuard()
+
+// NormalizeSignature - Lowercases a hex signature, so the signature that is compared is also
+// the one the replay guard remembers and a case change does not make a used signature new
+func NormalizeSignature(signature string) string {
+	return strings.ToLower(strings.TrimSpace(signature))
+}
+
+// authError - A failed authentication with the status it should be answered with
+type authError struct {
+	status  int
+	message string
+}
+
+// authenticate - Returns the token that a request is authenticated with, either a bearer
+// token or a signed request. A signed request's body is read and replaced
+func authenticate(r *http.Request) (Token, *authError) {
+	if len(Tokens()) == 0 {
+		return Token{}, &authError{http.StatusUnauthorized, "No admin tokens are configured on this server"}
+	}
+
+	if header := r.Header.Get("Authorization"); header != "" {
+		secret := strings.TrimPrefix(header, "Bearer ")
+		if secret == header {
+			return Token{}, &authError{http.StatusUnauthorized, "Authorization header must be a Bearer token"}
+		}
+		t, ok := bySecret(strings.TrimSpace(secret))
+		if !ok {
+			return Token{}, &authError{http.StatusUnauthorized, "Invalid bearer token"}
+		}
+		return t, nil
+	}
+
+	if name := r.Header.Get(KeyHeader); name != "" {
+		now := time.Now()
+		if err := CheckTimestamp(r.Header.Get(TimestampHeader), now); err != nil {
+			return Token{}, &authError{http.StatusUnauthorized, "Invalid signed request: " + err.Error()}
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxSignedBody))
+		if err != nil {
+			return Token{}, &authError{http.StatusRequestEntityTooLarge, "Could not read request body"}
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		t, ok := byName(name)
+		ts, _ := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		signature := NormalizeSignature(r.Header.Get(SignatureHeader))
+		if !ok || !hmac.Equal([]byte(Sign(t.Secret, r.Method, r.URL.RequestURI(), ts, body)), []byte(signature)) {
+			return Token{}, &authError{http.StatusUnauthorized, "Invalid request signature"}
+		}
+		if !replays.Check(signature, now) {
+			return Token{}, &authError{http.StatusUnauthorized, "Signed request was already used"}
+		}
+		return t, nil
+	}
+
+	return Token{}, &authError{http.StatusUnauthorized, "Missing credentials, send a Bearer token or a signed request"}
+}
+
+// Require - Only lets requests through that are authenticated with a token that has the scope
+func Require(scope Scope, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, err := authenticate(r)
+		if err != nil {
+			if err.status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			}
+			apierror.Write(w, r, err.status, err.message)
+			return
+		}
+		if !t.Has(scope) {
+			apierror.Write(w, r, http.StatusForbidden, fmt.Sprintf("Token %q does not have the %q scope", t.Name, scope))
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signed - Returns a request signed with secret at a time, the signature in upper case if
+// upper is set
+func signed(name string, secret string, at time.Time, body string, upper bool) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/admin/refresh?x=1", strings.NewReader(body))
+	sig := Sign(secret, r.Method, r.URL.RequestURI(), at.Unix(), []byte(body))
+	if upper {
+		sig = strings.ToUpper(sig)
+	}
+	r.Header.Set(KeyHeader, name)
+	r.Header.Set(TimestampHeader, strconv.FormatInt(at.Unix(), 10))
+	r.Header.Set(SignatureHeader, sig)
+	return r
+}
+
+func bearer(secret string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/admin/refresh", nil)
+	r.Header.Set("Authorization", "Bearer "+secret)
+	return r
+}
+
+func TestRequire(t *testing.T) {
+	tokens, err := ParseTokens("deploy:deploysecret:refresh,stats:statssecret:read-stats,ops:opssecret:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetTokens(tokens)
+	defer SetTokens(nil)
+	replays = NewReplayGuard()
+
+	now := time.Now()
+	replayed := signed("deploy", "deploysecret", now, "{}", false)
+	replayedAgain := signed("deploy", "deploysecret", now, "{}", true)
+
+	tests := []struct {
+		name   string
+		r      *http.Request
+		status int
+	}{
+		{"bearer token", bearer("deploysecret"), http.StatusOK},
+		{"every scope", bearer("opssecret"), http.StatusOK},
+		{"wrong bearer token", bearer("nope"), http.StatusUnauthorized},
+		{"wrong scope", bearer("statssecret"), http.StatusForbidden},
+		{"missing token", httptest.NewRequest(http.MethodPost, "/api/admin/refresh", nil), http.StatusUnauthorized},
+		{"not a bearer token", func() *http.Request {
+			r := bearer("deploysecret")
+			r.Header.Set("Authorization", "Basic deploysecret")
+			return r
+		}(), http.StatusUnauthorized},
+
+		{"valid signature", signed("deploy", "deploysecret", now, `{"a":1}`, false), http.StatusOK},
+		{"wrong secret", signed("deploy", "statssecret", now, `{"a":2}`, false), http.StatusUnauthorized},
+		{"unknown key", signed("nobody", "deploysecret", now, `{"a":3}`, false), http.StatusUnauthorized},
+		{"expired signature", signed("deploy", "deploysecret", now.Add(-2*MaxClockSkew), `{"a":4}`, false), http.StatusUnauthorized},
+		{"signature from the future", signed("deploy", "deploysecret", now.Add(2*MaxClockSkew), `{"a":5}`, false), http.StatusUnauthorized},
+		{"signed with the wrong scope", signed("stats", "statssecret", now, `{"a":6}`, false), http.StatusForbidden},
+		{"first use", replayed, http.StatusOK},
+		{"replayed signature", signed("deploy", "deploysecret", now, "{}", false), http.StatusUnauthorized},
+		// the same signature in upper case is still the used one
+		{"replayed in upper case", replayedAgain, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			w := httptest.NewRecorder()
+			Require(ScopeRefresh, func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			})(w, tt.r)
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if called != (tt.status == http.StatusOK) {
+				t.Errorf("handler called %v, want %v", called, tt.status == http.StatusOK)
+			}
+			if tt.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestRequireWithoutTokens(t *testing.T) {
+	SetTokens(nil)
+	w := httptest.NewRecorder()
+	Require(ScopeRefresh, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler called without configured tokens")
+	})(w, bearer(""))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status %d, want 401", w.Code)
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    int
+		wantErr bool
+	}{
+		{"a:s1:refresh, b:s2:moderate+read-stats", 2, false},
+		{"", 0, false},
+		{"a:s1", 0, true},
+		{"a::refresh", 0, true},
+		{"a:s1:refresh,a:s2:moderate", 0, true},
+		{"a:s1:delete", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTokens(tt.spec)
+		if (err != nil) != tt.wantErr || len(got) != tt.want {
+			t.Errorf("ParseTokens(%q) = %d tokens, %v", tt.spec, len(got), err)
+		}
+	}
+}
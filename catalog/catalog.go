@@ -0,0 +1,323 @@
+package catalog
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	ytwrapper "github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/random"
+	"github.com/lemonase/youtube-meme-api/search"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Entry - A single playable video in the catalog along with where it came from
+type Entry struct {
+	VideoID      string    `json:"videoId"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	ChannelID    string    `json:"channelId"`
+	ChannelTitle string    `json:"channelTitle"`
+	PublishedAt  time.Time `json:"publishedAt"`
+	// FirstSeen - when the video first showed up in a refresh
+	FirstSeen time.Time `json:"firstSeen"`
+	// DurationSeconds - the length of the video, 0 if unknown (playlist items have no duration)
+	DurationSeconds int `json:"durationSeconds,omitempty"`
+	// Source - the playlist ID for playlist items, the video ID for sheet videos
+	Source string `json:"source"`
+	// Weight - the curator weight of the source from the sheet (defaults to 1)
+	Weight float64 `json:"weight"`
+	// Tags - the curator tags of the source's sheet row
+	Tags []string `json:"tags,omitempty"`
+	// StartSeconds and EndSeconds - the clip of the video that is played, from the URL or
+	// the start and end columns of its row in the videos column, 0 for the start and end of the video
+	StartSeconds int `json:"startSeconds,omitempty"`
+	EndSeconds   int `json:"endSeconds,omitempty"`
+	// Credit - who the curator credits for the meme, from the credits column
+	Credit string `json:"credit,omitempty"`
+	// Original - YouTube's title, description and publish date, set if the sheet overrides
+	// any of them for the video
+	Original *Original `json:"original,omitempty"`
+	// dateLayout - how an overridden publish date is displayed, as precise as the curator's date
+	dateLayout string
+
+	// Either Video (and the VideoResponse it came from) or PlaylistItem is set
+	Video         *youtube.Video             `json:"video,omitempty"`
+	VideoResponse *youtube.VideoListResponse `json:"-"`
+	PlaylistItem  *youtube.PlaylistItem      `json:"playlistItem,omitempty"`
+}
+
+// WatchURL - Returns the YouTube watch page of the video, starting at the clip
+func (e *Entry) WatchURL() string {
+	if e.StartSeconds > 0 {
+		return "https://www.youtube.com/watch?v=" + e.VideoID + "&t=" + strconv.Itoa(e.StartSeconds) + "s"
+	}
+	return "https://www.youtube.com/watch?v=" + e.VideoID
+}
+
+// ThumbnailURL - Returns the high quality thumbnail of the video
+func (e *Entry) ThumbnailURL() string {
+	return "https://i.ytimg.com/vi/" + e.VideoID + "/hqdefault.jpg"
+}
+
+// Entries - A list of entries that can be picked from with a random.Strategy
+type Entries []*Entry
+
+// Len - implements random.Pool
+func (e Entries) Len() int { return len(e) }
+
+// ID - implements random.Pool
+func (e Entries) ID(i int) string { return e[i].VideoID }
+
+// Source - implements random.Pool
+func (e Entries) Source(i int) string { return e[i].Source }
+
+// Weight - implements random.Pool
+func (e Entries) Weight(i int) float64 { return e[i].Weight }
+
+// Published - implements random.Pool
+func (e Entries) Published(i int) time.Time { return e[i].PublishedAt }
+
+// Pick - Returns an entry chosen by the strategy, or nil if there is nothing to pick
+func (e Entries) Pick(src *random.Source, strategy random.Strategy) *Entry {
+	i := strategy.Pick(src, e)
+	if i < 0 || i >= len(e) {
+		return nil
+	}
+	return e[i]
+}
+
+// Snapshot - An immutable view of the catalog built after each refresh
+type Snapshot struct {
+	// Videos - videos from the video column of the sheet
+	Videos Entries
+	// PlaylistItems - every video of every playlist and channel upload playlist
+	PlaylistItems Entries
+	Playlists     []*youtube.PlaylistListResponse
+	Channels      []*youtube.ChannelListResponse
+	BuiltAt       time.Time
+
+	// The responses the snapshot was built from, served by the /all endpoints
+	VideoResponses        []*youtube.VideoListResponse
+	PlaylistItemResponses []*youtube.PlaylistItemListResponse
+
+	// Version - a hash of everything the API serves from the snapshot
+	Version string
+	// ModifiedAt - when the version last changed
+	ModifiedAt time.Time
+
+	// searchIndex - full text index over searchDocs
+	searchIndex *search.Index
+	searchDocs  []*SearchResult
+
+	tagsBySource  map[string][]string
+	videosByID    map[string]*Entry
+	itemsBySource map[string]Entries
+	playlistsByID map[string]*youtube.PlaylistListResponse
+	channelsByID  map[string]*youtube.ChannelListResponse
+}
+
+// Catalog - The sheet and YouTube responses of one catalog, the snapshot built from them and
+// the state kept between refreshes. Lock it while refreshing its sheet or responses
+type Catalog struct {
+	sync.Mutex
+	Sheet   *sheets.Sheet
+	YouTube *ytwrapper.Store
+	// Strategies - the strategies picks from this catalog use, with their own history
+	Strategies random.StrategySet
+
+	current   atomic.Value
+	firstSeen *firstSeenTimes
+}
+
+// New - Returns a catalog with an empty snapshot over the sheet and store
+func New(sheet *sheets.Sheet, store *ytwrapper.Store) *Catalog {
+	c := &Catalog{
+		Sheet:      sheet,
+		YouTube:    store,
+		Strategies: random.NewStrategySet(),
+		firstSeen:  &firstSeenTimes{store: store},
+	}
+	c.current.Store(&Snapshot{})
+	return c
+}
+
+// Main - The catalog of the main sheet
+var Main = New(sheets.Main, ytwrapper.Main)
+
+// Current - Returns the latest snapshot, never nil
+func (c *Catalog) Current() *Snapshot {
+	return c.current.Load().(*Snapshot)
+}
+
+// Refresh - Rebuilds the snapshot from the youtube responses and sheet values
+// and records when new videos were first seen
+func (c *Catalog) Refresh() *Snapshot {
+	snap := Build(c.Sheet, c.YouTube)
+	c.firstSeen.stamp(snap)
+	snap.stampVersion(c.Current())
+	c.current.Store(snap)
+	return snap
+}
+
+// Current - Returns the latest snapshot of the Main catalog
+func Current() *Snapshot {
+	return Main.Current()
+}
+
+// Refresh - Rebuilds the snapshot of the Main catalog
+func Refresh() *Snapshot {
+	return Main.Refresh()
+}
+
+// Build - Returns a new snapshot from the youtube responses of the store and values of the sheet
+func Build(sheet *sheets.Sheet, store *ytwrapper.Store) *Snapshot {
+	weights := sourceWeights(sheet, store)
+	tags := sourceTags(sheet, store)
+	clips := sourceClips(sheet)
+	overrides := sourceOverrides(sheet)
+	snap := &Snapshot{
+		Playlists: store.PlaylistResponses,
+		Channels:  store.ChannelResponses,
+		BuiltAt:   time.Now(),
+
+		tagsBySource: tags,
+
+		VideoResponses:        store.VideoResponses,
+		PlaylistItemResponses: store.PlaylistItemResponses,
+	}
+
+	for _, res := range store.VideoResponses {
+		for _, v := range res.Items {
+			e := &Entry{VideoID: v.Id, Source: v.Id, Video: v, VideoResponse: res}
+			if v.ContentDetails != nil {
+				e.DurationSeconds = parseDuration(v.ContentDetails.Duration)
+			}
+			if v.Snippet != nil {
+				e.Title = v.Snippet.Title
+				e.Description = v.Snippet.Description
+				e.ChannelID = v.Snippet.ChannelId
+				e.ChannelTitle = v.Snippet.ChannelTitle
+				e.PublishedAt = parseTime(v.Snippet.PublishedAt)
+			}
+			e.Weight = weightOf(weights, e.Source)
+			e.Tags = tags[e.Source]
+			e.setClip(clips[e.VideoID])
+			e.applyOverride(overrides[e.VideoID])
+			snap.Videos = append(snap.Videos, e)
+		}
+	}
+
+	for _, item := range store.AllPlaylistItems() {
+		e := &Entry{PlaylistItem: item}
+		if item.ContentDetails != nil {
+			e.VideoID = item.ContentDetails.VideoId
+			e.PublishedAt = parseTime(item.ContentDetails.VideoPublishedAt)
+		}
+		if item.Snippet != nil {
+			e.Title = item.Snippet.Title
+			e.Description = item.Snippet.Description
+			e.ChannelID = item.Snippet.VideoOwnerChannelId
+			e.ChannelTitle = item.Snippet.VideoOwnerChannelTitle
+			e.Source = item.Snippet.PlaylistId
+		}
+		e.Weight = weightOf(weights, e.Source)
+		e.Tags = tags[e.Source]
+		// a clip and overrides of a video on the sheet also apply where it is in a playlist
+		e.setClip(clips[e.VideoID])
+		e.applyOverride(overrides[e.VideoID])
+		snap.PlaylistItems = append(snap.PlaylistItems, e)
+	}
+
+	snap.index()
+	snap.indexSearch()
+	return snap
+}
+
+// sourceWeights - Maps video and playlist IDs to the weight in the column next to them
+func sourceWeights(sheet *sheets.Sheet, store *ytwrapper.Store) map[string]float64 {
+	weights := make(map[string]float64)
+
+	for i := range sheet.VideoValues {
+		if id, err := ytwrapper.VideoIDFromURL(sheets.CellString(sheet.VideoValues, i)); err == nil {
+			setWeight(weights, id, sheets.CellString(sheet.VideoWeightValues, i))
+		}
+	}
+	for i := range sheet.PlaylistValues {
+		if id, err := ytwrapper.PlaylistIDFromURL(sheets.CellString(sheet.PlaylistValues, i)); err == nil {
+			setWeight(weights, id, sheets.CellString(sheet.PlaylistWeightValues, i))
+		}
+	}
+	for i := range sheet.ChannelValues {
+		if uploads := channelUploads(sheet, store, i); uploads != "" {
+			setWeight(weights, uploads, sheets.CellString(sheet.ChannelWeightValues, i))
+		}
+	}
+
+	return weights
+}
+
+// channelUploads - Returns the uploads playlist ID of the channel on a row of the channels column,
+// matched to the loaded responses by ID or custom URL since failed fetches leave no response
+func channelUploads(sheet *sheets.Sheet, store *ytwrapper.Store, row int) string {
+	id, err := ytwrapper.ChannelIDFromURL(sheets.CellString(sheet.ChannelValues, row))
+	if err != nil {
+		return ""
+	}
+	return store.ChannelUploads(id)
+}
+
+func setWeight(weights map[string]float64, id string, cell string) {
+	if cell == "" {
+		return
+	}
+	if w, err := strconv.ParseFloat(cell, 64); err == nil && w >= 0 {
+		weights[id] = w
+	}
+}
+
+func weightOf(weights map[string]float64, id string) float64 {
+	if w, ok := weights[id]; ok {
+		return w
+	}
+	return 1
+}
+
+// parseDuration - Parses an ISO 8601 duration as returned by the API (e.g. "PT1H2M3S")
+// into seconds, returning 0 if it can not be parsed
+func parseDuration(s string) int {
+	if !strings.HasPrefix(s, "P") {
+		return 0
+	}
+	units := map[byte]int{'W': 7 * 24 * 3600, 'D': 24 * 3600, 'H': 3600, 'M': 60, 'S': 1}
+	seconds, number, inTime := 0, 0, false
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			number = number*10 + int(c-'0')
+		case c == 'T':
+			inTime = true
+		case c == 'M' && !inTime:
+			// months are not used for video lengths
+			return 0
+		case units[c] > 0:
+			seconds += number * units[c]
+			number = 0
+		default:
+			return 0
+		}
+	}
+	return seconds
+}
+
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
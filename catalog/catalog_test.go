@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	ytwrapper "github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+// newTestCatalog - A catalog of one sheet video whose responses are already loaded
+func newTestCatalog(t *testing.T, videoID string) *Catalog {
+	sheet := &sheets.Sheet{VideoValues: [][]interface{}{{"https://youtu.be/" + videoID}}, VideoLength: 1}
+	store := &ytwrapper.Store{
+		DataDirectory: t.TempDir(),
+		Sheet:         sheet,
+		VideoResponses: []*youtube.VideoListResponse{{Items: []*youtube.Video{{
+			Id:      videoID,
+			Snippet: &youtube.VideoSnippet{Title: videoID, PublishedAt: "2020-01-01T00:00:00Z"},
+		}}}},
+	}
+	return New(sheet, store)
+}
+
+// TestCatalogsRefreshConcurrently - Catalogs refreshed at the same time only see their own sheet
+// and responses, and keep their first seen times next to their own responses
+func TestCatalogsRefreshConcurrently(t *testing.T) {
+	ids := []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc"}
+	catalogs := make([]*Catalog, len(ids))
+	for i, id := range ids {
+		catalogs[i] = newTestCatalog(t, id)
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range catalogs {
+		wg.Add(1)
+		go func(c *Catalog) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				c.Lock()
+				c.Refresh()
+				c.Unlock()
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	for i, c := range catalogs {
+		snap := c.Current()
+		if len(snap.Videos) != 1 || snap.Videos[0].VideoID != ids[i] {
+			t.Errorf("catalog %d has %d videos, want only %s", i, len(snap.Videos), ids[i])
+			continue
+		}
+		// the first refresh of an empty data directory dates videos by their publish date
+		if got := snap.Videos[0].FirstSeen.Format("2006-01-02"); got != "2020-01-01" {
+			t.Errorf("catalog %d: first seen %s, want the publish date", i, got)
+		}
+		if _, err := os.Stat(filepath.Join(c.YouTube.DataDirectory, FirstSeenFileName)); err != nil {
+			t.Errorf("catalog %d: %v", i, err)
+		}
+	}
+}
+
+// TestCatalogStrategies - Every catalog has its own lru history
+func TestCatalogStrategies(t *testing.T) {
+	a, b := newTestCatalog(t, "aaaaaaaaaaa"), newTestCatalog(t, "bbbbbbbbbbb")
+	lruA, _ := a.Strategies.ByName("lru")
+	lruB, _ := b.Strategies.ByName("lru")
+	if lruA == lruB {
+		t.Error("two catalogs share one lru strategy")
+	}
+}
@@ -0,0 +1,122 @@
+package catalog
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	ytwrapper "github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+)
+
+// Clip - The part of a video that is played, in seconds from the start. 0 means from the
+// start or to the end
+type Clip struct {
+	Start int
+	End   int
+}
+
+// ParseOffset - Parses a time in a video like "90", "90s", "1m30s" or "1:30" into seconds
+func ParseOffset(s string) (int, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, false
+	}
+	if strings.Contains(s, ":") {
+		parts := strings.Split(s, ":")
+		if len(parts) > 3 {
+			return 0, false
+		}
+		seconds := 0
+		for _, p := range parts {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 0 {
+				return 0, false
+			}
+			seconds = seconds*60 + n
+		}
+		return seconds, true
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, n >= 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return int(d.Seconds()), true
+}
+
+// ClipFromURL - Reads the clip of a video URL from its start= or t= parameter (or a #t= fragment)
+// and its end= parameter
+func ClipFromURL(raw string) Clip {
+	var c Clip
+	u, err := url.Parse(raw)
+	if err != nil {
+		return c
+	}
+	q := u.Query()
+	fragment, _ := url.ParseQuery(u.Fragment)
+	for _, start := range []string{q.Get("start"), q.Get("t"), fragment.Get("t")} {
+		if n, ok := ParseOffset(start); ok {
+			c.Start = n
+			break
+		}
+	}
+	c.End, _ = ParseOffset(q.Get("end"))
+	return c.valid()
+}
+
+// valid - Drops an end that is not after the start
+func (c Clip) valid() Clip {
+	if c.End <= c.Start {
+		c.End = 0
+	}
+	return c
+}
+
+// sourceClips - Maps the IDs of the videos column to the clip in their URL, with the start
+// and end columns of the same row taking precedence
+func sourceClips(sheet *sheets.Sheet) map[string]Clip {
+	clips := make(map[string]Clip)
+	for i := range sheet.VideoValues {
+		videoURL := sheets.CellString(sheet.VideoValues, i)
+		id, err := ytwrapper.VideoIDFromURL(videoURL)
+		if err != nil {
+			continue
+		}
+		c := ClipFromURL(videoURL)
+		if n, ok := ParseOffset(sheets.CellString(sheet.StartValues, i)); ok {
+			c.Start = n
+		}
+		if n, ok := ParseOffset(sheets.CellString(sheet.EndValues, i)); ok {
+			c.End = n
+		}
+		if c = c.valid(); c != (Clip{}) {
+			clips[id] = c
+		}
+	}
+	return clips
+}
+
+// setClip - Sets the clip times of an entry
+func (e *Entry) setClip(c Clip) {
+	e.StartSeconds, e.EndSeconds = c.Start, c.End
+}
+
+// EmbedURL - Returns the embedded player URL of the video, playing only the clip if it has one
+func (e *Entry) EmbedURL() string {
+	q := url.Values{}
+	if e.StartSeconds > 0 {
+		q.Set("start", strconv.Itoa(e.StartSeconds))
+	}
+	if e.EndSeconds > 0 {
+		q.Set("end", strconv.Itoa(e.EndSeconds))
+	}
+	embed := "https://www.youtube.com/embed/" + e.VideoID
+	if len(q) > 0 {
+		embed += "?" + q.Encode()
+	}
+	return embed
+}
@@ -0,0 +1,97 @@
+package catalog
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Filter - Restricts which entries are picked or listed
+type Filter struct {
+	// Source - only entries from this playlist ID (or channel uploads playlist ID)
+	Source string
+	// Channel - only videos uploaded by this channel ID
+	Channel string
+	// Tags - only entries with every one of these tags
+	Tags []string
+	// ExcludeTags - no entries with any of these tags
+	ExcludeTags []string
+}
+
+// FilterFromQuery - Reads a filter from the query parameters of a request,
+// tag and excludeTag can be repeated or hold comma separated tags
+func FilterFromQuery(q url.Values) Filter {
+	return Filter{
+		Source:      q.Get("source"),
+		Channel:     q.Get("channel"),
+		Tags:        ParseTags(strings.Join(q["tag"], ",")),
+		ExcludeTags: ParseTags(strings.Join(q["excludeTag"], ",")),
+	}
+}
+
+// IsZero - Reports whether the filter lets every entry through
+func (f Filter) IsZero() bool {
+	return f.Source == "" && f.Channel == "" && len(f.Tags) == 0 && len(f.ExcludeTags) == 0
+}
+
+// Key - Returns a string that identifies the filter, equal filters have equal keys
+func (f Filter) Key() string {
+	if f.IsZero() {
+		return ""
+	}
+	q := url.Values{}
+	if f.Source != "" {
+		q.Set("source", f.Source)
+	}
+	if f.Channel != "" {
+		q.Set("channel", f.Channel)
+	}
+	if len(f.Tags) > 0 {
+		q.Set("tag", sortedJoin(f.Tags))
+	}
+	if len(f.ExcludeTags) > 0 {
+		q.Set("excludeTag", sortedJoin(f.ExcludeTags))
+	}
+	return q.Encode()
+}
+
+func sortedJoin(list []string) string {
+	sorted := append([]string{}, list...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// Match - Reports whether an entry passes the filter
+func (f Filter) Match(e *Entry) bool {
+	if f.Source != "" && e.Source != f.Source {
+		return false
+	}
+	if f.Channel != "" && e.ChannelID != f.Channel {
+		return false
+	}
+	for _, t := range f.Tags {
+		if !e.HasTag(t) {
+			return false
+		}
+	}
+	for _, t := range f.ExcludeTags {
+		if e.HasTag(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply - Returns the entries that pass the filter, in the same order
+func (f Filter) Apply(entries Entries) Entries {
+	if f.IsZero() {
+		return entries
+	}
+	var matched Entries
+	for _, e := range entries {
+		if f.Match(e) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
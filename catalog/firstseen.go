@@ -0,0 +1,106 @@
+package catalog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	ytwrapper "github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+)
+
+// FirstSeenFileName - File in the data directory that keeps when each video was first seen
+var FirstSeenFileName = "first_seen.json"
+
+// firstSeenTimes - First seen times by video ID of one catalog, persisted next to the
+// cached responses of its store
+type firstSeenTimes struct {
+	mu    sync.Mutex
+	store *ytwrapper.Store
+	// dir - the data directory the times were loaded from, "" before the first load
+	dir   string
+	times map[string]time.Time
+}
+
+func (f *firstSeenTimes) file() string {
+	return filepath.Join(f.store.DataDirectory, FirstSeenFileName)
+}
+
+// load - Reads the persisted times on the first stamp, and again only if the store's data
+// directory changed since. If there is no file yet (the first run) the publish date is used
+// for videos already in the sheet, so they do not all look new
+func (f *firstSeenTimes) load() (bootstrap bool) {
+	if f.dir == f.store.DataDirectory {
+		return false
+	}
+	f.dir = f.store.DataDirectory
+	f.times = make(map[string]time.Time)
+
+	data, err := ioutil.ReadFile(f.file())
+	if os.IsNotExist(err) {
+		return true
+	} else if err != nil {
+		slog.Warn("could not read first seen times", "file", f.file(), "err", err)
+		return false
+	}
+	if err := json.Unmarshal(data, &f.times); err != nil {
+		slog.Warn("could not parse first seen times", "file", f.file(), "err", err)
+	}
+	return false
+}
+
+// save - Writes the times to a temporary file and moves it into place
+func (f *firstSeenTimes) save() {
+	j, err := json.Marshal(f.times)
+	if err != nil {
+		slog.Error("could not marshal first seen times", "err", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(f.file()), 0755); err != nil {
+		slog.Error("could not create directory", "dir", filepath.Dir(f.file()), "err", err)
+		return
+	}
+	tmp := f.file() + ".tmp"
+	if err := ioutil.WriteFile(tmp, j, 0644); err != nil {
+		slog.Error("could not write first seen times", "file", tmp, "err", err)
+		return
+	}
+	if err := os.Rename(tmp, f.file()); err != nil {
+		slog.Error("could not replace first seen times", "file", f.file(), "err", err)
+	}
+}
+
+// stamp - Sets FirstSeen on every entry of the snapshot, recording new videos as seen now
+func (f *firstSeenTimes) stamp(snap *Snapshot) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bootstrap := f.load()
+	now := time.Now().UTC()
+	changed := false
+
+	for _, entries := range []Entries{snap.Videos, snap.PlaylistItems} {
+		for _, e := range entries {
+			if e.VideoID == "" {
+				continue
+			}
+			seen, ok := f.times[e.VideoID]
+			if !ok {
+				seen = now
+				if bootstrap && !e.PublishedAt.IsZero() {
+					seen = e.PublishedAt
+				}
+				f.times[e.VideoID] = seen
+				changed = true
+			}
+			e.FirstSeen = seen
+		}
+	}
+
+	if changed {
+		f.save()
+	}
+}
@@ -0,0 +1,138 @@
+package catalog
+
+import (
+	"sort"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// index - Builds the lookup maps of a snapshot
+func (s *Snapshot) index() {
+	s.videosByID = make(map[string]*Entry)
+	s.itemsBySource = make(map[string]Entries)
+	s.playlistsByID = make(map[string]*youtube.PlaylistListResponse)
+	s.channelsByID = make(map[string]*youtube.ChannelListResponse)
+
+	// videos from the sheet take precedence over the same video in a playlist
+	for _, e := range s.Videos {
+		if _, ok := s.videosByID[e.VideoID]; !ok {
+			s.videosByID[e.VideoID] = e
+		}
+	}
+	for _, e := range s.PlaylistItems {
+		if _, ok := s.videosByID[e.VideoID]; !ok && e.VideoID != "" {
+			s.videosByID[e.VideoID] = e
+		}
+		s.itemsBySource[e.Source] = append(s.itemsBySource[e.Source], e)
+	}
+	for _, res := range s.Playlists {
+		if len(res.Items) > 0 {
+			s.playlistsByID[res.Items[0].Id] = res
+		}
+	}
+	for _, res := range s.Channels {
+		if len(res.Items) > 0 {
+			s.channelsByID[res.Items[0].Id] = res
+		}
+	}
+}
+
+// Video - Returns the entry for a video ID, or nil if it is not in the catalog
+func (s *Snapshot) Video(id string) *Entry {
+	return s.videosByID[id]
+}
+
+// Playlist - Returns the playlist response for a playlist ID, or nil if it is not in the catalog
+func (s *Snapshot) Playlist(id string) *youtube.PlaylistListResponse {
+	return s.playlistsByID[id]
+}
+
+// PlaylistItemsOf - Returns the entries of a playlist in playlist order
+func (s *Snapshot) PlaylistItemsOf(id string) Entries {
+	return s.itemsBySource[id]
+}
+
+// Channel - Returns the channel response for a channel ID, or nil if it is not in the catalog
+func (s *Snapshot) Channel(id string) *youtube.ChannelListResponse {
+	return s.channelsByID[id]
+}
+
+// Unique - Returns every video matching the filter once, sheet videos first
+// and then playlist items in playlist order
+func (s *Snapshot) Unique(filter Filter) Entries {
+	var unique Entries
+	seen := make(map[string]bool)
+	for _, entries := range []Entries{s.Videos, s.PlaylistItems} {
+		for _, e := range filter.Apply(entries) {
+			if e.VideoID == "" || seen[e.VideoID] {
+				continue
+			}
+			seen[e.VideoID] = true
+			unique = append(unique, e)
+		}
+	}
+	return unique
+}
+
+// Newest - Returns up to limit unique videos matching the filter, most recently
+// first seen first (0 means no limit)
+func (s *Snapshot) Newest(filter Filter, limit int) Entries {
+	newest := s.Unique(filter)
+
+	sort.SliceStable(newest, func(i, j int) bool {
+		if !newest[i].FirstSeen.Equal(newest[j].FirstSeen) {
+			return newest[i].FirstSeen.After(newest[j].FirstSeen)
+		}
+		return newest[i].PublishedAt.After(newest[j].PublishedAt)
+	})
+	if limit > 0 && len(newest) > limit {
+		newest = newest[:limit]
+	}
+	return newest
+}
+
+// VideoResponsesMatching - Returns the video responses with only the videos that pass the filter,
+// responses left without videos are dropped
+func (s *Snapshot) VideoResponsesMatching(filter Filter) []*youtube.VideoListResponse {
+	matched := make(map[*youtube.Video]bool)
+	for _, e := range filter.Apply(s.Videos) {
+		matched[e.Video] = true
+	}
+	pages := []*youtube.VideoListResponse{}
+	for _, res := range s.VideoResponses {
+		page := *res
+		page.Items = nil
+		for _, v := range res.Items {
+			if matched[v] {
+				page.Items = append(page.Items, v)
+			}
+		}
+		if len(page.Items) > 0 {
+			pages = append(pages, &page)
+		}
+	}
+	return pages
+}
+
+// PlaylistItemResponsesMatching - Returns the playlist item responses with only the items that
+// pass the filter, responses left without items are dropped
+func (s *Snapshot) PlaylistItemResponsesMatching(filter Filter) []*youtube.PlaylistItemListResponse {
+	matched := make(map[*youtube.PlaylistItem]bool)
+	for _, e := range filter.Apply(s.PlaylistItems) {
+		matched[e.PlaylistItem] = true
+	}
+	pages := []*youtube.PlaylistItemListResponse{}
+	for _, res := range s.PlaylistItemResponses {
+		page := *res
+		page.Items = nil
+		for _, item := range res.Items {
+			if matched[item] {
+				page.Items = append(page.Items, item)
+			}
+		}
+		if len(page.Items) > 0 {
+			pages = append(pages, &page)
+		}
+	}
+	return pages
+}
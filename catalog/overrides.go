@@ -0,0 +1,96 @@
+package catalog
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	ytwrapper "github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+)
+
+// Original - The YouTube metadata of an entry that curator overrides replaced
+type Original struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// override - Curator metadata from a row of the videos column, empty fields keep YouTube's
+type override struct {
+	title       string
+	description string
+	publishedAt time.Time
+	dateLayout  string
+	credit      string
+}
+
+// dateLayouts - The date formats of the dates column, with the layout they are displayed in
+var dateLayouts = []struct{ parse, display string }{
+	{time.RFC3339, "January 2, 2006"},
+	{"2006-01-02", "January 2, 2006"},
+	{"2006-01", "January 2006"},
+	{"2006", "2006"},
+}
+
+// ParseDate - Parses a date like "2019-03-12", "2019-03" or "2019" and returns it with
+// the layout it is displayed in, which is only as precise as the date
+func ParseDate(s string) (time.Time, string, bool) {
+	s = strings.TrimSpace(s)
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l.parse, s); err == nil {
+			return t, l.display, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// sourceOverrides - Maps the IDs of the videos column to the curator metadata on their rows
+func sourceOverrides(sheet *sheets.Sheet) map[string]override {
+	overrides := make(map[string]override)
+	for i := range sheet.VideoValues {
+		id, err := ytwrapper.VideoIDFromURL(sheets.CellString(sheet.VideoValues, i))
+		if err != nil {
+			continue
+		}
+		o := override{
+			title:       sheets.CellString(sheet.TitleValues, i),
+			description: sheets.CellString(sheet.NoteValues, i),
+			credit:      sheets.CellString(sheet.CreditValues, i),
+		}
+		o.publishedAt, o.dateLayout, _ = ParseDate(sheets.CellString(sheet.DateValues, i))
+		if o != (override{}) {
+			overrides[id] = o
+		}
+	}
+	return overrides
+}
+
+// applyOverride - Replaces the entry's metadata with the curator's, keeping YouTube's in Original
+func (e *Entry) applyOverride(o override) {
+	e.Credit = o.credit
+	if o.title == "" && o.description == "" && o.publishedAt.IsZero() {
+		return
+	}
+	e.Original = &Original{Title: e.Title, Description: e.Description, PublishedAt: e.PublishedAt}
+	if o.title != "" {
+		e.Title = o.title
+	}
+	if o.description != "" {
+		e.Description = o.description
+	}
+	if !o.publishedAt.IsZero() {
+		e.PublishedAt, e.dateLayout = o.publishedAt, o.dateLayout
+	}
+}
+
+// DisplayDate - Returns the publish date as it is shown on pages, the year unless the
+// curator set a more precise date
+func (e *Entry) DisplayDate() string {
+	if e.PublishedAt.IsZero() {
+		return ""
+	}
+	if e.dateLayout != "" {
+		return e.PublishedAt.Format(e.dateLayout)
+	}
+	return e.PublishedAt.Format("2006")
+}
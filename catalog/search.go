@@ -0,0 +1,97 @@
+package catalog
+
+import (
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/search"
+	"google.golang.org/api/youtube/v3"
+)
+
+// snippetWords - The number of words in a highlighted description snippet
+const snippetWords = 30
+
+// SearchResult - A video or playlist that matched a search
+type SearchResult struct {
+	// Kind - "video" or "playlist"
+	Kind  string  `json:"kind"`
+	ID    string  `json:"id"`
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+	// Highlights - HTML snippets of the title and description with matches in <mark> tags
+	Highlights map[string]string `json:"highlights,omitempty"`
+
+	Video    *Entry                        `json:"video,omitempty"`
+	Playlist *youtube.PlaylistListResponse `json:"playlist,omitempty"`
+
+	description string
+}
+
+// Highlight - Returns a copy of the result with highlights for the query
+func (r SearchResult) Highlight(query string) *SearchResult {
+	r.Highlights = map[string]string{
+		"title":       search.Highlight(r.Title, query, 0),
+		"description": search.Highlight(r.description, query, snippetWords),
+	}
+	return &r
+}
+
+// indexSearch - Builds the full text index over unique videos and playlists
+func (s *Snapshot) indexSearch() {
+	var docs []search.Document
+	s.searchDocs = nil
+
+	seen := make(map[string]bool)
+	for _, entries := range []Entries{s.Videos, s.PlaylistItems} {
+		for _, e := range entries {
+			if e.VideoID == "" || seen[e.VideoID] {
+				continue
+			}
+			seen[e.VideoID] = true
+			s.searchDocs = append(s.searchDocs, &SearchResult{Kind: "video", ID: e.VideoID, Title: e.Title, Video: e, description: e.Description})
+			fields := []search.Field{
+				{Name: "title", Text: e.Title, Boost: 3},
+				{Name: "channel", Text: e.ChannelTitle, Boost: 2},
+				{Name: "description", Text: e.Description, Boost: 1},
+				{Name: "tags", Text: strings.Join(e.Tags, " "), Boost: 2},
+				{Name: "credit", Text: e.Credit, Boost: 2},
+			}
+			// the YouTube title stays searchable when the curator replaced it
+			if e.Original != nil {
+				fields = append(fields, search.Field{Name: "original", Text: e.Original.Title, Boost: 1})
+			}
+			docs = append(docs, search.Document{Fields: fields})
+		}
+	}
+
+	for _, res := range s.Playlists {
+		if len(res.Items) < 1 || res.Items[0].Snippet == nil {
+			continue
+		}
+		pl := res.Items[0]
+		s.searchDocs = append(s.searchDocs, &SearchResult{Kind: "playlist", ID: pl.Id, Title: pl.Snippet.Title, Playlist: res, description: pl.Snippet.Description})
+		docs = append(docs, search.Document{Fields: []search.Field{
+			{Name: "title", Text: pl.Snippet.Title, Boost: 3},
+			{Name: "channel", Text: pl.Snippet.ChannelTitle, Boost: 2},
+			{Name: "description", Text: pl.Snippet.Description, Boost: 1},
+			{Name: "tags", Text: strings.Join(s.TagsOf(pl.Id), " "), Boost: 2},
+		}})
+	}
+
+	s.searchIndex = search.NewIndex(docs)
+}
+
+// Search - Returns the videos and playlists matching the query, best matches first.
+// kind restricts results to "video" or "playlist", an empty kind returns both
+func (s *Snapshot) Search(query string, kind string) []*SearchResult {
+	var results []*SearchResult
+	for _, hit := range s.searchIndex.Search(query) {
+		doc := s.searchDocs[hit.Doc]
+		if kind != "" && doc.Kind != kind {
+			continue
+		}
+		r := *doc
+		r.Score = hit.Score
+		results = append(results, &r)
+	}
+	return results
+}
@@ -0,0 +1,99 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	ytwrapper "github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+)
+
+// TagCount - A tag and the number of unique videos that have it
+type TagCount struct {
+	Tag    string `json:"tag"`
+	Videos int    `json:"videos"`
+}
+
+// ParseTags - Splits a comma separated tags cell into trimmed, lowercase tags without duplicates
+func ParseTags(cell string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(cell, ",") {
+		t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	return tags
+}
+
+// sourceTags - Maps video, playlist and channel uploads playlist IDs to the tags on their rows
+func sourceTags(sheet *sheets.Sheet, store *ytwrapper.Store) map[string][]string {
+	tags := make(map[string][]string)
+	add := func(id string, row int) {
+		for _, t := range ParseTags(sheets.CellString(sheet.TagValues, row)) {
+			if !contains(tags[id], t) {
+				tags[id] = append(tags[id], t)
+			}
+		}
+	}
+
+	for i := range sheet.VideoValues {
+		if id, err := ytwrapper.VideoIDFromURL(sheets.CellString(sheet.VideoValues, i)); err == nil {
+			add(id, i)
+		}
+	}
+	for i := range sheet.PlaylistValues {
+		if id, err := ytwrapper.PlaylistIDFromURL(sheets.CellString(sheet.PlaylistValues, i)); err == nil {
+			add(id, i)
+		}
+	}
+	for i := range sheet.ChannelValues {
+		if uploads := channelUploads(sheet, store, i); uploads != "" {
+			add(uploads, i)
+		}
+	}
+	return tags
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag - Reports whether the entry has the tag
+func (e *Entry) HasTag(tag string) bool {
+	return contains(e.Tags, tag)
+}
+
+// TagsOf - Returns the tags of a video, playlist or channel uploads playlist ID
+func (s *Snapshot) TagsOf(id string) []string {
+	return s.tagsBySource[id]
+}
+
+// Tags - Returns every tag with the number of unique videos that have it, most used first
+func (s *Snapshot) Tags() []TagCount {
+	counts := make(map[string]int)
+	for _, e := range s.Unique(Filter{}) {
+		for _, t := range e.Tags {
+			counts[t]++
+		}
+	}
+	tags := make([]TagCount, 0, len(counts))
+	for t, n := range counts {
+		tags = append(tags, TagCount{Tag: t, Videos: n})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Videos != tags[j].Videos {
+			return tags[i].Videos > tags[j].Videos
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags
+}
@@ -0,0 +1,38 @@
+package catalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// computeVersion - Hashes the responses and the entry fields that do not come from them
+func (s *Snapshot) computeVersion() string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, v := range []interface{}{s.VideoResponses, s.Playlists, s.PlaylistItemResponses, s.Channels} {
+		if err := enc.Encode(v); err != nil {
+			slog.Error("could not hash catalog", "err", err)
+		}
+	}
+	for _, list := range []Entries{s.Videos, s.PlaylistItems} {
+		for _, e := range list {
+			fmt.Fprintf(h, "%s %s %g %d %s %d %d %q %q %d %q\n", e.VideoID, e.Source, e.Weight, e.FirstSeen.Unix(), strings.Join(e.Tags, ","),
+				e.StartSeconds, e.EndSeconds, e.Title, e.Description, e.PublishedAt.Unix(), e.Credit)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// stampVersion - Sets the version, keeping the previous snapshot's modification
+// time when nothing changed
+func (s *Snapshot) stampVersion(prev *Snapshot) {
+	s.Version = s.computeVersion()
+	s.ModifiedAt = s.BuiltAt
+	if prev != nil && prev.Version == s.Version {
+		s.ModifiedAt = prev.ModifiedAt
+	}
+}
@@ -5,15 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 
+	"github.com/lemonase/youtube-meme-api/metrics"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
+	htransport "google.golang.org/api/transport/http"
 	"google.golang.org/api/youtube/v3"
 )
 
@@ -27,17 +29,43 @@ var Services ServiceClients
 
 var tokenFile string
 
+// ctx - Passed to every API call, cancelled on shutdown
+var ctx, cancel = context.WithCancel(context.Background())
+
+// Context - Returns the context API calls are made with, it is done once Cancel was called
+func Context() context.Context {
+	return ctx
+}
+
+// Cancel - Cancels running API calls and makes new ones fail, used to stop refreshes on shutdown
+func Cancel() {
+	cancel()
+}
+
+// fatal - Logs an error that keeps the clients from being created and exits
+func fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func InitClientsWithAPIKey(apiKey string) {
 	sheetsCtx := context.Background()
 	youtubeCtx := context.Background()
 
-	sheetsClient, err := sheets.NewService(sheetsCtx, option.WithAPIKey(apiKey), option.WithScopes(sheets.SpreadsheetsReadonlyScope))
+	// the key is added by a transport wrapping the metrics one, so API calls are counted
+	keyTransport, err := htransport.NewTransport(context.Background(), metrics.Transport(nil), option.WithAPIKey(apiKey))
+	if err != nil {
+		fatal("could not create API key transport", "err", err)
+	}
+	httpClient := option.WithHTTPClient(&http.Client{Transport: keyTransport})
+
+	sheetsClient, err := sheets.NewService(sheetsCtx, httpClient)
 	if err != nil {
-		log.Fatalf("Could not get sheets client %v\n", err)
+		fatal("could not get sheets client", "err", err)
 	}
-	youtubeClient, err := youtube.NewService(youtubeCtx, option.WithAPIKey(apiKey), option.WithScopes(youtube.YoutubeReadonlyScope))
+	youtubeClient, err := youtube.NewService(youtubeCtx, httpClient)
 	if err != nil {
-		log.Fatalf("Could not get youtube client %v\n", err)
+		fatal("could not get youtube client", "err", err)
 	}
 
 	Services.Sheets = *sheetsClient
@@ -55,18 +83,18 @@ func InitClientsWithSecretJSONFile(filename string) {
 func getSheetsClientOAuth(credsFilename string) *sheets.Service {
 	b, err := ioutil.ReadFile(credsFilename)
 	if err != nil {
-		log.Fatalf("Unable to read client secret file (%s): %v", credsFilename, err)
+		fatal("unable to read client secret file", "file", credsFilename, "err", err)
 	}
 
 	config, err := google.ConfigFromJSON(b, sheets.SpreadsheetsReadonlyScope)
 	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+		fatal("unable to parse client secret file to config", "file", credsFilename, "err", err)
 	}
 
 	client := getClientWithToken(config)
 	sheetsClient, err := sheets.New(client)
 	if err != nil {
-		log.Fatalf("Could not get sheets client%v\n", err)
+		fatal("could not get sheets client", "err", err)
 	}
 
 	return sheetsClient
@@ -77,18 +105,18 @@ func getSheetsClientOAuth(credsFilename string) *sheets.Service {
 func getYoutubeClientOAuth(credsFilename string) *youtube.Service {
 	b, err := ioutil.ReadFile(credsFilename)
 	if err != nil {
-		log.Fatalf("Unable to read client secret file (%s): %v", credsFilename, err)
+		fatal("unable to read client secret file", "file", credsFilename, "err", err)
 	}
 
 	config, err := google.ConfigFromJSON(b, youtube.YoutubeReadonlyScope)
 	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+		fatal("unable to parse client secret file to config", "file", credsFilename, "err", err)
 	}
 
 	client := getClientWithToken(config)
 	youtubeClient, err := youtube.New(client)
 	if err != nil {
-		log.Fatalf("Error obtaining client: %v\n", err.Error())
+		fatal("could not get youtube client", "err", err)
 	}
 
 	return youtubeClient
@@ -103,7 +131,9 @@ func getClientWithToken(config *oauth2.Config) *http.Client {
 		tok = getTokenFromWeb(config)
 		saveToken(tokenFile, tok)
 	}
-	return config.Client(context.Background(), tok)
+	client := config.Client(context.Background(), tok)
+	client.Transport = metrics.Transport(client.Transport)
+	return client
 }
 
 // Request a token from the web, then returns the retrieved token.
@@ -114,12 +144,12 @@ func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
 
 	var authCode string
 	if _, err := fmt.Scan(&authCode); err != nil {
-		log.Fatalf("Unable to read authorization code: %v", err)
+		fatal("unable to read authorization code", "err", err)
 	}
 
 	tok, err := config.Exchange(context.TODO(), authCode)
 	if err != nil {
-		log.Fatalf("Unable to retrieve token from web: %v", err)
+		fatal("unable to retrieve token from web", "err", err)
 	}
 	return tok
 }
@@ -141,7 +171,7 @@ func saveToken(path string, token *oauth2.Token) {
 	fmt.Printf("Saving credential file to: %s\n", path)
 	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+		fatal("unable to cache oauth token", "file", path, "err", err)
 	}
 	defer f.Close()
 	json.NewEncoder(f).Encode(token)
@@ -0,0 +1,151 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/lemonase/youtube-meme-api/httpcache"
+)
+
+// Content codings, Identity is the uncompressed body
+const (
+	Brotli   = "br"
+	Gzip     = "gzip"
+	Identity = "identity"
+)
+
+// preference - Codings from best to worst, used to break ties between equal q-values
+var preference = []string{Brotli, Gzip, Identity}
+
+// Negotiate - Picks the content coding for a request from its Accept-Encoding header
+func Negotiate(r *http.Request) string {
+	header := r.Header.Get("Accept-Encoding")
+	if header == "" {
+		return Identity
+	}
+
+	q := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		weight := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					weight = parsed
+				}
+			}
+		}
+		q[coding] = weight
+	}
+
+	weightOf := func(coding string) float64 {
+		if w, ok := q[coding]; ok {
+			return w
+		}
+		if w, ok := q["*"]; ok {
+			return w
+		}
+		if coding == Identity {
+			return 0.001
+		}
+		return 0
+	}
+
+	codings := append([]string{}, preference...)
+	sort.SliceStable(codings, func(i, j int) bool { return weightOf(codings[i]) > weightOf(codings[j]) })
+	if weightOf(codings[0]) > 0 {
+		return codings[0]
+	}
+	return Identity
+}
+
+// compressibleTypes - Media types (besides text/*) that are worth compressing
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/x-ndjson":   true,
+	"application/xml":        true,
+	"application/javascript": true,
+	"application/feed+json":  true,
+	"application/atom+xml":   true,
+	"application/rss+xml":    true,
+	"application/xspf+xml":   true,
+	"audio/x-mpegurl":        true,
+	"image/svg+xml":          true,
+}
+
+// Compressible - Reports whether responses of a content type are worth compressing
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressibleTypes[mediaType] ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// NewWriter - Returns a writer that compresses to w with a coding at a level
+// from 0 (fastest) to 1 (smallest), or nil for Identity
+func NewWriter(w io.Writer, coding string, level float64) io.WriteCloser {
+	switch coding {
+	case Gzip:
+		gz, _ := gzip.NewWriterLevel(w, gzip.BestSpeed+int(level*float64(gzip.BestCompression-gzip.BestSpeed)))
+		return gz
+	case Brotli:
+		return brotli.NewWriterLevel(w, brotli.BestSpeed+int(level*float64(brotli.BestCompression-brotli.BestSpeed)))
+	}
+	return nil
+}
+
+// Body - A response body in every supported coding, to be compressed once and served many times
+type Body struct {
+	ContentType string
+	encoded     map[string][]byte
+}
+
+// NewBody - Compresses data in every coding at the highest level
+func NewBody(contentType string, data []byte) *Body {
+	b := &Body{ContentType: contentType, encoded: map[string][]byte{Identity: data}}
+	for _, coding := range []string{Gzip, Brotli} {
+		var buf bytes.Buffer
+		zw := NewWriter(&buf, coding, 1)
+		zw.Write(data)
+		zw.Close()
+		b.encoded[coding] = buf.Bytes()
+	}
+	return b
+}
+
+// Len - Returns the size of the body in a coding
+func (b *Body) Len(coding string) int {
+	return len(b.encoded[coding])
+}
+
+// Serve - Writes the body in the coding the client prefers
+func (b *Body) Serve(w http.ResponseWriter, r *http.Request, status int) {
+	coding := Negotiate(r)
+	data, ok := b.encoded[coding]
+	if !ok {
+		coding, data = Identity, b.encoded[Identity]
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", b.ContentType)
+	httpcache.Vary(h, "Accept-Encoding")
+	if coding != Identity {
+		h.Set("Content-Encoding", coding)
+	}
+	h.Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		w.Write(data)
+	}
+}
@@ -0,0 +1,131 @@
+package compress
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/lemonase/youtube-meme-api/httpcache"
+)
+
+// MinSize - Responses smaller than this are sent uncompressed
+var MinSize = 1024
+
+// Level - The compression level of the middleware, from 0 (fastest) to 1 (smallest)
+var Level = 0.3
+
+// flusher - A compressing writer that can flush what it has so far
+type flusher interface {
+	Flush() error
+}
+
+// responseWriter - Buffers the start of a response to decide whether to compress it,
+// then streams it through a compressor or straight to the client
+type responseWriter struct {
+	http.ResponseWriter
+	r      *http.Request
+	coding string
+
+	status  int
+	buf     []byte
+	decided bool
+	zw      io.WriteCloser
+}
+
+// Middleware - Compresses responses with the coding the client prefers, unless they are
+// small, already compressed or of a type that does not compress well
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		coding := Negotiate(r)
+		if coding == Identity {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &responseWriter{ResponseWriter: w, r: r, coding: coding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	// bodiless responses are never compressed
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified || w.r.Method == http.MethodHead {
+		w.decide(false)
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.zw != nil {
+			return w.zw.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= MinSize {
+		w.decide(true)
+	}
+	return len(p), nil
+}
+
+// Flush - Sends what has been written so far, a streamed response is compressed
+// even if its first part is small
+func (w *responseWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.WriteHeader(http.StatusOK)
+		}
+		w.decide(true)
+	}
+	if f, ok := w.zw.(flusher); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// decide - Sends the headers and the buffered start of the body, compressing from now on if
+// allowed and the response is compressible
+func (w *responseWriter) decide(allowed bool) {
+	w.decided = true
+	h := w.Header()
+	if Compressible(h.Get("Content-Type")) {
+		httpcache.Vary(h, "Accept-Encoding")
+	}
+	if allowed && h.Get("Content-Encoding") == "" && Compressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", w.coding)
+		h.Del("Content-Length")
+		w.zw = NewWriter(w.ResponseWriter, w.coding, Level)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) > 0 {
+		if w.zw != nil {
+			w.zw.Write(w.buf)
+		} else {
+			w.ResponseWriter.Write(w.buf)
+		}
+		w.buf = nil
+	}
+}
+
+// close - Sends whatever is still buffered and finishes the compressed stream
+func (w *responseWriter) close() {
+	if w.status == 0 {
+		return
+	}
+	if !w.decided {
+		w.decide(len(w.buf) >= MinSize)
+	}
+	if w.zw != nil {
+		w.zw.Close()
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile - The config file that is read if none is given and it exists
+const DefaultFile = "config.yaml"
+
+// Config - Every setting of the server. Settings come from Default, then the config file,
+// then the environment and then the command line, later ones override earlier ones
+type Config struct {
+	Server    Server    `yaml:"server"`
+	Google    Google    `yaml:"google"`
+	Sheet     Sheet     `yaml:"sheet"`
+	YouTube   YouTube   `yaml:"youtube"`
+	Refresh   Refresh   `yaml:"refresh"`
+	Site      Site      `yaml:"site"`
+	Auth      Auth      `yaml:"auth"`
+	RateLimit RateLimit `yaml:"rateLimit"`
+	Log       Log       `yaml:"log"`
+	// Strategy - the default selection strategy for random picks
+	Strategy string `yaml:"strategy"`
+}
+
+// Server - Where the server listens and how it shuts down
+type Server struct {
+	// Listen - the listen address, a port alone ("8000") listens on every interface
+	Listen       string        `yaml:"listen"`
+	DrainTimeout time.Duration `yaml:"drainTimeout"`
+}
+
+// Google - The credentials for the Sheets and YouTube APIs, either an API key or an OAuth client file
+type Google struct {
+	APIKey     string `yaml:"apiKey"`
+	SecretFile string `yaml:"secretFile"`
+}
+
+// Sheet - The Google Sheet the catalog comes from
+type Sheet struct {
+	ID     string `yaml:"id"`
+	Ranges Ranges `yaml:"ranges"`
+}
+
+// Ranges - The single column A1 ranges of the sheet
+type Ranges struct {
+	Videos          string `yaml:"videos"`
+	VideoWeights    string `yaml:"videoWeights"`
+	Playlists       string `yaml:"playlists"`
+	PlaylistWeights string `yaml:"playlistWeights"`
+	Channels        string `yaml:"channels"`
+	ChannelWeights  string `yaml:"channelWeights"`
+	Searches        string `yaml:"searches"`
+	Tags            string `yaml:"tags"`
+	Starts          string `yaml:"starts"`
+	Ends            string `yaml:"ends"`
+	Titles          string `yaml:"titles"`
+	Notes           string `yaml:"notes"`
+	Dates           string `yaml:"dates"`
+	Credits         string `yaml:"credits"`
+}
+
+// YouTube - How YouTube responses are fetched and stored
+type YouTube struct {
+	// PageSize - items per API call, at most 50
+	PageSize int64 `yaml:"pageSize"`
+	// DataDir - where responses (and first seen times) are stored
+	DataDir string `yaml:"dataDir"`
+}
+
+// Refresh - The refresh schedule
+type Refresh struct {
+	// Interval - how often everything is refetched, 0 only refreshes on admin requests and webhooks
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Site - Texts of the pages and feeds
+type Site struct {
+	Title     string `yaml:"title"`
+	HomeTitle string `yaml:"homeTitle"`
+}
+
+// Auth - Admin tokens, the webhook secret and client keys, see the auth and ratelimit packages for the formats
+type Auth struct {
+	AdminTokens string `yaml:"adminTokens"`
+	HookSecret  string `yaml:"hookSecret"`
+	ClientKeys  string `yaml:"clientKeys"`
+}
+
+// RateLimit - Rate limit budgets and the proxies whose X-Forwarded-For is trusted
+type RateLimit struct {
+	Budgets        string `yaml:"budgets"`
+	TrustedProxies string `yaml:"trustedProxies"`
+}
+
+// Log - The log format and level
+type Log struct {
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+}
+
+// Default - Returns the settings used when nothing else is given
+func Default() *Config {
+	return &Config{
+		Server: Server{Listen: ":8000", DrainTimeout: 10 * time.Second},
+		Sheet: Sheet{
+			ID: "1MuvC8JpJte1wzAS0m9qR0rr2-gxzL8aaX6lvlKeAqvs",
+			Ranges: Ranges{
+				Videos:          "Sheet1!A2:A1000",
+				VideoWeights:    "Sheet1!B2:B1000",
+				Playlists:       "Sheet1!C2:C1000",
+				PlaylistWeights: "Sheet1!D2:D1000",
+				Channels:        "Sheet1!E2:E1000",
+				ChannelWeights:  "Sheet1!F2:F1000",
+				Searches:        "Sheet1!G2:G1000",
+				Tags:            "Sheet1!H2:H1000",
+				Starts:          "Sheet1!I2:I1000",
+				Ends:            "Sheet1!J2:J1000",
+				Titles:          "Sheet1!K2:K1000",
+				Notes:           "Sheet1!L2:L1000",
+				Dates:           "Sheet1!M2:M1000",
+				Credits:         "Sheet1!N2:N1000",
+			},
+		},
+		YouTube: YouTube{PageSize: 50, DataDir: "data"},
+		Site:    Site{Title: "YT Meme Shuffle 🔀", HomeTitle: "Welcome to the Meme Shuffler"},
+		Log:     Log{Format: "text", Level: "info"},
+		// empty budgets keep the defaults of the ratelimit package
+		Strategy: "uniform",
+	}
+}
+
+// Load - Returns the Default config overridden by the file (if not ""), the environment
+// read with getenv and the flags, in that order. The config is not validated
+func Load(file string, getenv func(string) string, flags Flags) (*Config, error) {
+	c := Default()
+	if file != "" {
+		if err := c.readFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs Errors
+	for _, s := range settings {
+		if s.env == "" {
+			continue
+		}
+		if v := getenv(s.env); v != "" {
+			if err := set(s.field(c), v); err != nil {
+				errs = append(errs, fmt.Errorf("%s (from %s): %v", s.name, s.env, err))
+			}
+		}
+	}
+	for _, s := range settings {
+		if v, ok := flags[s.flag]; ok && s.flag != "" {
+			if err := set(s.field(c), v); err != nil {
+				errs = append(errs, fmt.Errorf("%s (from --%s): %v", s.name, s.flag, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// a port alone listens on every interface
+	if c.Server.Listen != "" && !strings.Contains(c.Server.Listen, ":") {
+		c.Server.Listen = ":" + c.Server.Listen
+	}
+	return c, nil
+}
+
+// readFile - Reads a YAML config file over c, unknown keys are errors so typos do not go unnoticed
+func (c *Config) readFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %v", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid config file %s: %v", file, err)
+	}
+	return nil
+}
+
+// Redacted - Returns a copy without the API key and secrets
+func (c *Config) Redacted() *Config {
+	r := *c
+	for _, s := range []*string{&r.Google.APIKey, &r.Auth.AdminTokens, &r.Auth.HookSecret, &r.Auth.ClientKeys} {
+		if *s != "" {
+			*s = "<redacted>"
+		}
+	}
+	return &r
+}
+
+// YAML - Returns the config in the format of the config file
+func (c *Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// Errors - Every problem found in a config
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = "  " + err.Error()
+	}
+	return "invalid config:\n" + strings.Join(msgs, "\n")
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// setting - A setting with its path in the config file and its names in the environment
+// and on the command line ("" if it can not be set there)
+type setting struct {
+	name  string
+	env   string
+	flag  string
+	usage string
+	field func(c *Config) interface{}
+}
+
+var settings = []setting{
+	{"server.listen", "PORT", "port", "Listen address or port", func(c *Config) interface{} { return &c.Server.Listen }},
+	{"server.drainTimeout", "DRAIN_TIMEOUT", "drainTimeout", "How long to wait for running requests and refreshes on SIGINT or SIGTERM", func(c *Config) interface{} { return &c.Server.DrainTimeout }},
+	{"google.apiKey", "YT_API_KEY", "key", "API key to access Google resources", func(c *Config) interface{} { return &c.Google.APIKey }},
+	{"google.secretFile", "GOOGLE_SECRET_FILE", "secretFile", "Credentials file downloaded from GCP (/path/to/credentials.json)", func(c *Config) interface{} { return &c.Google.SecretFile }},
+	{"sheet.id", "SHEET_ID", "sheetID", "ID of the Google Sheet the catalog comes from", func(c *Config) interface{} { return &c.Sheet.ID }},
+	{"sheet.ranges.videos", "SHEET_VIDEO_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Videos }},
+	{"sheet.ranges.videoWeights", "SHEET_VIDEO_WEIGHT_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.VideoWeights }},
+	{"sheet.ranges.playlists", "SHEET_PLAYLIST_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Playlists }},
+	{"sheet.ranges.playlistWeights", "SHEET_PLAYLIST_WEIGHT_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.PlaylistWeights }},
+	{"sheet.ranges.channels", "SHEET_CHANNEL_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Channels }},
+	{"sheet.ranges.channelWeights", "SHEET_CHANNEL_WEIGHT_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.ChannelWeights }},
+	{"sheet.ranges.searches", "SHEET_SEARCH_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Searches }},
+	{"sheet.ranges.tags", "SHEET_TAG_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Tags }},
+	{"sheet.ranges.starts", "SHEET_START_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Starts }},
+	{"sheet.ranges.ends", "SHEET_END_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Ends }},
+	{"sheet.ranges.titles", "SHEET_TITLE_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Titles }},
+	{"sheet.ranges.notes", "SHEET_NOTE_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Notes }},
+	{"sheet.ranges.dates", "SHEET_DATE_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Dates }},
+	{"sheet.ranges.credits", "SHEET_CREDIT_RANGE", "", "", func(c *Config) interface{} { return &c.Sheet.Ranges.Credits }},
+	{"youtube.pageSize", "PAGE_SIZE", "pageSize", "Items per YouTube API call (at most 50)", func(c *Config) interface{} { return &c.YouTube.PageSize }},
+	{"youtube.dataDir", "DATA_DIR", "dataDir", "Directory the responses are stored in", func(c *Config) interface{} { return &c.YouTube.DataDir }},
+	{"refresh.interval", "REFRESH_INTERVAL", "refreshInterval", "How often everything is refetched (e.g. 24h), 0 only refreshes on admin requests and webhooks", func(c *Config) interface{} { return &c.Refresh.Interval }},
+	{"site.title", "SITE_TITLE", "siteTitle", "Name of the site in page and feed titles", func(c *Config) interface{} { return &c.Site.Title }},
+	{"site.homeTitle", "HOME_TITLE", "", "", func(c *Config) interface{} { return &c.Site.HomeTitle }},
+	{"auth.adminTokens", "ADMIN_TOKENS", "adminTokens", "Admin API tokens as name:secret:scope+scope, comma separated (scopes: refresh, moderate, read-stats or *)", func(c *Config) interface{} { return &c.Auth.AdminTokens }},
+	{"auth.hookSecret", "SHEETS_HOOK_SECRET", "hookSecret", "Secret that /hooks/sheets payloads are signed with (see scripts/sheets-webhook.gs)", func(c *Config) interface{} { return &c.Auth.HookSecret }},
+	{"auth.clientKeys", "CLIENT_API_KEYS", "clientKeys", "Client API keys with their own rate limit budgets as name:key, comma separated (sent in X-API-Key)", func(c *Config) interface{} { return &c.Auth.ClientKeys }},
+	{"rateLimit.budgets", "RATE_LIMITS", "rateLimits", "Rate limit budgets as budget=count/unit[:burst] or budget=off, comma separated (defaults: random=10/s:30,list=1/s:10,admin=30/m:10)", func(c *Config) interface{} { return &c.RateLimit.Budgets }},
+	{"rateLimit.trustedProxies", "TRUSTED_PROXIES", "trustedProxies", "Comma separated IPs or CIDRs of proxies whose X-Forwarded-For header is trusted", func(c *Config) interface{} { return &c.RateLimit.TrustedProxies }},
+	{"log.format", "LOG_FORMAT", "log-format", "Log format: text or json", func(c *Config) interface{} { return &c.Log.Format }},
+	{"log.level", "LOG_LEVEL", "log-level", "Lowest level that is logged: debug, info, warn or error", func(c *Config) interface{} { return &c.Log.Level }},
+	{"strategy", "STRATEGY", "strategy", "Default selection strategy for random picks", func(c *Config) interface{} { return &c.Strategy }},
+}
+
+// set - Parses a value into a field of the config
+func set(field interface{}, value string) error {
+	switch f := field.(type) {
+	case *string:
+		*f = value
+	case *int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*f = n
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s or 24h", value)
+		}
+		*f = d
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+	return nil
+}
+
+// format - Formats a field of the config like set parses it
+func format(field interface{}) string {
+	switch f := field.(type) {
+	case *string:
+		return *f
+	case *int64:
+		return strconv.FormatInt(*f, 10)
+	case *time.Duration:
+		return f.String()
+	}
+	return ""
+}
+
+// Flags - The settings given on the command line by flag name
+type Flags map[string]string
+
+// flagValue - Keeps the value of a setting flag without parsing it, Load parses it
+type flagValue struct {
+	name  string
+	value string
+	flags Flags
+}
+
+func (v *flagValue) String() string { return v.value }
+
+func (v *flagValue) Set(s string) error {
+	v.flags[v.name] = s
+	return nil
+}
+
+// RegisterFlags - Adds a flag for every setting that has one to fs, the given values
+// end up in the returned Flags once fs is parsed
+func RegisterFlags(fs *flag.FlagSet) Flags {
+	flags := make(Flags)
+	defaults := Default()
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		usage := fmt.Sprintf("%s (%s, env %s)", s.usage, s.name, s.env)
+		fs.Var(&flagValue{name: s.flag, value: format(s.field(defaults)), flags: flags}, s.flag, usage)
+	}
+	return flags
+}
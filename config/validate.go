@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	"github.com/lemonase/youtube-meme-api/auth"
+	"github.com/lemonase/youtube-meme-api/logging"
+	"github.com/lemonase/youtube-meme-api/random"
+	"github.com/lemonase/youtube-meme-api/ratelimit"
+)
+
+// MaxPageSize - The most items the YouTube API returns per call
+const MaxPageSize = 50
+
+// MinRefreshInterval - Scheduled refreshes refetch everything, which costs API quota
+const MinRefreshInterval = time.Minute
+
+// Validate - Checks every setting and returns all problems as Errors, or nil
+func (c *Config) Validate() error {
+	var errs Errors
+	add := func(name string, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", name, fmt.Sprintf(format, args...)))
+	}
+	check := func(name string, err error) {
+		if err != nil {
+			add(name, "%v", err)
+		}
+	}
+
+	if host, port, err := net.SplitHostPort(c.Server.Listen); err != nil {
+		add("server.listen", "%q is not an address like :8000 or 127.0.0.1:8000", c.Server.Listen)
+	} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		add("server.listen", "%q has no valid port", c.Server.Listen)
+	} else if host != "" && net.ParseIP(host) == nil && host != "localhost" {
+		add("server.listen", "%q is not an IP address", host)
+	}
+	if c.Server.DrainTimeout <= 0 {
+		add("server.drainTimeout", "must be more than 0")
+	}
+
+	switch {
+	case c.Google.APIKey == "" && c.Google.SecretFile == "":
+		add("google", "either apiKey (YT_API_KEY, --key) or secretFile (--secretFile) has to be set")
+	case c.Google.APIKey != "" && c.Google.SecretFile != "":
+		add("google", "only one of apiKey and secretFile can be set")
+	case c.Google.SecretFile != "":
+		if _, err := os.Stat(c.Google.SecretFile); err != nil {
+			add("google.secretFile", "%v", err)
+		}
+	}
+
+	if c.Sheet.ID == "" {
+		add("sheet.id", "must not be empty")
+	}
+	for _, r := range []struct{ name, value string }{
+		{"videos", c.Sheet.Ranges.Videos}, {"videoWeights", c.Sheet.Ranges.VideoWeights},
+		{"playlists", c.Sheet.Ranges.Playlists}, {"playlistWeights", c.Sheet.Ranges.PlaylistWeights},
+		{"channels", c.Sheet.Ranges.Channels}, {"channelWeights", c.Sheet.Ranges.ChannelWeights},
+		{"searches", c.Sheet.Ranges.Searches}, {"tags", c.Sheet.Ranges.Tags},
+		{"starts", c.Sheet.Ranges.Starts}, {"ends", c.Sheet.Ranges.Ends},
+		{"titles", c.Sheet.Ranges.Titles}, {"notes", c.Sheet.Ranges.Notes},
+		{"dates", c.Sheet.Ranges.Dates}, {"credits", c.Sheet.Ranges.Credits},
+	} {
+		_, err := sheets.ParseRange(r.value)
+		check("sheet.ranges."+r.name, err)
+	}
+
+	if c.YouTube.PageSize < 1 || c.YouTube.PageSize > MaxPageSize {
+		add("youtube.pageSize", "must be between 1 and %d", MaxPageSize)
+	}
+	if c.YouTube.DataDir == "" {
+		add("youtube.dataDir", "must not be empty")
+	}
+	if c.Refresh.Interval < 0 || c.Refresh.Interval > 0 && c.Refresh.Interval < MinRefreshInterval {
+		add("refresh.interval", "must be 0 (off) or at least %s", MinRefreshInterval)
+	}
+	if c.Site.Title == "" {
+		add("site.title", "must not be empty")
+	}
+
+	_, err := auth.ParseTokens(c.Auth.AdminTokens)
+	check("auth.adminTokens", err)
+	_, err = ratelimit.ParseAPIKeys(c.Auth.ClientKeys)
+	check("auth.clientKeys", err)
+	_, err = ratelimit.ParseBudgets(c.RateLimit.Budgets)
+	check("rateLimit.budgets", err)
+	_, err = ratelimit.ParseCIDRs(c.RateLimit.TrustedProxies)
+	check("rateLimit.trustedProxies", err)
+
+	if !contains(logging.Formats, c.Log.Format) {
+		add("log.format", "%q is not one of %v", c.Log.Format, logging.Formats)
+	}
+	_, err = logging.ParseLevel(c.Log.Level)
+	check("log.level", err)
+	_, err = random.StrategyByName(c.Strategy)
+	check("strategy", err)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
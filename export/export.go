@@ -0,0 +1,175 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/random"
+)
+
+// Track - A single video in an exported playlist
+type Track struct {
+	Title    string
+	Creator  string
+	URL      string
+	ImageURL string
+	// DurationSeconds - 0 if the length is unknown
+	DurationSeconds int
+}
+
+// Options - Which videos are exported and in which order
+type Options struct {
+	Filter catalog.Filter
+	// Shuffle - order the tracks by the permutation of Seed instead of catalog order
+	Shuffle bool
+	Seed    int64
+}
+
+// OptionsFromQuery - Reads the same filters as the random endpoints. A seed shuffles the
+// tracks in the same order a shuffle session with that seed would, shuffle=true picks a new seed
+func OptionsFromQuery(q url.Values) (Options, error) {
+	opts := Options{Filter: catalog.FilterFromQuery(q)}
+
+	shuffle, _ := strconv.ParseBool(q.Get("shuffle"))
+	if seed := q.Get("seed"); seed != "" || shuffle {
+		parsed, err := random.ParseSeed(seed)
+		if err != nil {
+			return opts, fmt.Errorf("seed must be an integer")
+		}
+		opts.Shuffle, opts.Seed = true, parsed
+	}
+	return opts, nil
+}
+
+// Tracks - Returns the tracks of the snapshot for the options
+func Tracks(snap *catalog.Snapshot, opts Options) []Track {
+	entries := snap.Unique(opts.Filter)
+	if opts.Shuffle {
+		shuffled := make(catalog.Entries, len(entries))
+		for i, j := range random.Permutation(opts.Seed, len(entries)) {
+			shuffled[i] = entries[j]
+		}
+		entries = shuffled
+	}
+
+	tracks := make([]Track, len(entries))
+	for i, e := range entries {
+		tracks[i] = Track{
+			Title:           e.Title,
+			Creator:         e.ChannelTitle,
+			URL:             e.WatchURL(),
+			ImageURL:        e.ThumbnailURL(),
+			DurationSeconds: e.DurationSeconds,
+		}
+	}
+	return tracks
+}
+
+// oneLine - Removes line breaks, which would end an M3U directive early
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WriteM3U - Writes the tracks as an extended M3U playlist
+func WriteM3U(w io.Writer, title string, tracks []Track) error {
+	if _, err := fmt.Fprintf(w, "#EXTM3U\n#PLAYLIST:%s\n", oneLine(title)); err != nil {
+		return err
+	}
+	for _, t := range tracks {
+		duration := -1
+		if t.DurationSeconds > 0 {
+			duration = t.DurationSeconds
+		}
+		name := oneLine(t.Title)
+		if t.Creator != "" {
+			name = oneLine(t.Creator) + " - " + name
+		}
+		if _, err := fmt.Fprintf(w, "#EXTINF:%d,%s\n%s\n", duration, name, t.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Image    string `xml:"image,omitempty"`
+	// Duration - in milliseconds
+	Duration int `xml:"duration,omitempty"`
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+// WriteXSPF - Writes the tracks as an XSPF playlist
+func WriteXSPF(w io.Writer, title string, tracks []Track) error {
+	pl := xspfPlaylist{Version: "1", Title: title}
+	for _, t := range tracks {
+		pl.Tracks = append(pl.Tracks, xspfTrack{
+			Location: t.URL,
+			Title:    t.Title,
+			Creator:  t.Creator,
+			Image:    t.ImageURL,
+			Duration: t.DurationSeconds * 1000,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(pl); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Format - A playlist format by file extension
+type Format struct {
+	ContentType string
+	Write       func(w io.Writer, title string, tracks []Track) error
+}
+
+// Formats - The supported playlist formats by file extension
+var Formats = map[string]Format{
+	"m3u":  {ContentType: "audio/x-mpegurl; charset=utf-8", Write: WriteM3U},
+	"xspf": {ContentType: "application/xspf+xml; charset=utf-8", Write: WriteXSPF},
+}
+
+// FormatOf - Returns the format for the extension of a file name
+func FormatOf(name string) (Format, bool) {
+	f, ok := Formats[strings.TrimPrefix(filepath.Ext(name), ".")]
+	return f, ok
+}
+
+// WriteFile - Writes the tracks to a file in the format of its extension
+func WriteFile(name string, title string, tracks []Track) error {
+	format, ok := FormatOf(name)
+	if !ok {
+		return fmt.Errorf("unknown playlist format for %s, use .m3u or .xspf", name)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := format.Write(f, title, tracks); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,251 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Feed - A list of items that can be written as Atom, RSS or JSON Feed
+type Feed struct {
+	Title       string
+	Description string
+	// SiteURL - the page the feed is about
+	SiteURL string
+	// FeedURL - where the feed itself is served
+	FeedURL string
+	Updated time.Time
+	Items   []Item
+}
+
+// Item - A single entry of a feed
+type Item struct {
+	ID        string
+	URL       string
+	Title     string
+	Summary   string
+	Author    string
+	ImageURL  string
+	Published time.Time
+	Updated   time.Time
+	// Categories - the item's tags
+	Categories []string
+}
+
+// Atom
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   atomAuthor  `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// WriteAtom - Writes the feed as an Atom 1.0 document
+func WriteAtom(w io.Writer, f *Feed) error {
+	af := atomFeed{
+		Title:    f.Title,
+		Subtitle: f.Description,
+		ID:       f.FeedURL,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links:    []atomLink{{Rel: "self", Href: f.FeedURL}, {Rel: "alternate", Href: f.SiteURL}},
+		Author:   atomAuthor{Name: f.Title},
+	}
+	for _, item := range f.Items {
+		e := atomEntry{
+			Title:   item.Title,
+			ID:      item.ID,
+			Link:    atomLink{Rel: "alternate", Href: item.URL},
+			Updated: item.Updated.UTC().Format(time.RFC3339),
+			Summary: item.Summary,
+		}
+		if !item.Published.IsZero() {
+			e.Published = item.Published.UTC().Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			e.Author = &atomAuthor{Name: item.Author}
+		}
+		for _, c := range item.Categories {
+			e.Categories = append(e.Categories, atomCategory{Term: c})
+		}
+		af.Entries = append(af.Entries, e)
+	}
+	return writeXML(w, af)
+}
+
+// RSS
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// WriteRSS - Writes the feed as an RSS 2.0 document
+func WriteRSS(w io.Writer, f *Feed) error {
+	rf := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.SiteURL,
+			Description:   f.Description,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			// RSS has no self link, so the Atom one is borrowed
+			AtomLink: atomLink{Rel: "self", Href: f.FeedURL},
+		},
+	}
+	for _, item := range f.Items {
+		rf.Channel.Items = append(rf.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{IsPermaLink: false, Value: item.ID},
+			PubDate:     item.Updated.UTC().Format(time.RFC1123Z),
+			Description: item.Summary,
+			Creator:     item.Author,
+			Categories:  item.Categories,
+		})
+	}
+	return writeXML(w, rf)
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// JSON Feed
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// WriteJSON - Writes the feed as a JSON Feed 1.1 document
+func WriteJSON(w io.Writer, f *Feed) error {
+	jf := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		Description: f.Description,
+		HomePageURL: f.SiteURL,
+		FeedURL:     f.FeedURL,
+		Items:       []jsonFeedItem{},
+	}
+	for _, item := range f.Items {
+		ji := jsonFeedItem{
+			ID:          item.ID,
+			URL:         item.URL,
+			Title:       item.Title,
+			ContentText: item.Summary,
+			Image:       item.ImageURL,
+			// items are dated by when they were added to the catalog
+			DatePublished: item.Updated.UTC().Format(time.RFC3339),
+			Tags:          item.Categories,
+		}
+		if item.Author != "" {
+			ji.Authors = []jsonFeedAuthor{{Name: item.Author}}
+		}
+		jf.Items = append(jf.Items, ji)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jf)
+}
+
+// Format - A feed format by file extension
+type Format struct {
+	ContentType string
+	Write       func(w io.Writer, f *Feed) error
+}
+
+// Formats - The supported feed formats by file extension
+var Formats = map[string]Format{
+	"atom": {ContentType: "application/atom+xml; charset=utf-8", Write: WriteAtom},
+	"rss":  {ContentType: "application/rss+xml; charset=utf-8", Write: WriteRSS},
+	"json": {ContentType: "application/feed+json; charset=utf-8", Write: WriteJSON},
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	"github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/render"
+)
+
+// CatalogCounts - The number of items in a catalog snapshot
+type CatalogCounts struct {
+	Videos        int       `json:"videos"`
+	PlaylistItems int       `json:"playlistItems"`
+	Playlists     int       `json:"playlists"`
+	Channels      int       `json:"channels"`
+	BuiltAt       time.Time `json:"builtAt"`
+}
+
+// RefreshResponse - The result of an admin refresh
+type RefreshResponse struct {
+	// Refreshed - what was refreshed: "all", "videos", "playlists", "channels" or "tenant"
+	Refreshed string `json:"refreshed"`
+	// Changed - false if the sheet column had the same length and nothing was refetched
+	Changed bool          `json:"changed"`
+	Catalog CatalogCounts `json:"catalog"`
+	// Errors - the items that could not be fetched, the rest were refreshed
+	Errors []string `json:"errors,omitempty"`
+}
+
+// AdminStats - Server statistics for admins
+type AdminStats struct {
+	Catalog         CatalogCounts `json:"catalog"`
+	ShuffleSessions int           `json:"shuffleSessions"`
+}
+
+// catalogCounts - Returns the counts of the main catalog's current snapshot
+func catalogCounts() CatalogCounts {
+	return countsOf(catalog.Main.Current())
+}
+
+// countsOf - Returns the counts of a snapshot
+func countsOf(snap *catalog.Snapshot) CatalogCounts {
+	return CatalogCounts{
+		Videos:        len(snap.Videos),
+		PlaylistItems: len(snap.PlaylistItems),
+		Playlists:     len(snap.Playlists),
+		Channels:      len(snap.Channels),
+		BuiltAt:       snap.BuiltAt,
+	}
+}
+
+// errorMessages - Returns the messages of an error, one per item for youtube.FetchErrors
+func errorMessages(err error) []string {
+	if err == nil {
+		return nil
+	}
+	if errs, ok := err.(youtube.FetchErrors); ok {
+		var msgs []string
+		for _, e := range errs {
+			msgs = append(msgs, errorMessages(e)...)
+		}
+		return msgs
+	}
+	return []string{err.Error()}
+}
+
+// writeRefresh - Writes the result of a refresh, err lists the items that could not be fetched
+func writeRefresh(w http.ResponseWriter, r *http.Request, refreshed string, changed bool, err error) {
+	render.Write(w, r, http.StatusOK, RefreshResponse{Refreshed: refreshed, Changed: changed, Catalog: catalogCounts(), Errors: errorMessages(err)})
+}
+
+// refreshFailed - Writes the error of a refresh that loaded nothing, the previous data is still served
+func refreshFailed(w http.ResponseWriter, r *http.Request, refreshed string, err error) {
+	apierror.WriteDetails(w, r, http.StatusBadGateway, "Could not refresh "+refreshed+", the previous data is still served",
+		map[string][]string{"errors": errorMessages(err)})
+}
+
+// UpdateAllValuesFromSheet - Updates json files by enforcing refresh
+func UpdateAllValuesFromSheet(w http.ResponseWriter, r *http.Request) {
+	catalog.Main.Lock()
+	defer catalog.Main.Unlock()
+
+	err := FetchAllYoutubeInfoFromSheet(true)
+	if err != nil && (len(catalog.Main.Current().Videos)+len(catalog.Main.Current().PlaylistItems) == 0 || youtube.Canceled()) {
+		refreshFailed(w, r, "all", err)
+		return
+	}
+	writeRefresh(w, r, "all", true, err)
+}
+
+// UpdateAllChannelsFromSheet - Refetches channel responses and forces refresh
+func UpdateAllChannelsFromSheet(w http.ResponseWriter, r *http.Request) {
+	catalog.Main.Lock()
+	defer catalog.Main.Unlock()
+
+	oldLen := sheets.Main.ChannelLength
+
+	if err := sheets.Main.FetchChannelValues(); err != nil {
+		refreshFailed(w, r, "channels", err)
+		return
+	}
+	changed := oldLen != sheets.Main.ChannelLength
+	var err error
+	if changed {
+		var replaced bool
+		if replaced, err = youtube.Main.RefetchChannels(); !replaced {
+			refreshFailed(w, r, "channels", err)
+			return
+		}
+		catalog.Main.Refresh()
+	}
+	writeRefresh(w, r, "channels", changed, err)
+}
+
+// UpdateAllPlaylistsFromSheet - Refetches playlist responses and forces refresh
+func UpdateAllPlaylistsFromSheet(w http.ResponseWriter, r *http.Request) {
+	catalog.Main.Lock()
+	defer catalog.Main.Unlock()
+
+	oldLen := sheets.Main.PlaylistLength
+
+	if err := sheets.Main.FetchPlaylistValues(); err != nil {
+		refreshFailed(w, r, "playlists", err)
+		return
+	}
+	changed := oldLen != sheets.Main.PlaylistLength
+	var err error
+	if changed {
+		oldPlaylists, oldItems := youtube.Main.PlaylistResponses, youtube.Main.PlaylistItemResponses
+		youtube.Main.PlaylistResponses = nil
+		youtube.Main.PlaylistItemResponses = nil
+		if err = youtube.Main.FetchOrRead("playlist", true); err == nil || len(youtube.Main.PlaylistResponses) > 0 {
+			if itemsErr := youtube.Main.FetchOrRead("playlistItem", true); itemsErr != nil && err != nil {
+				err = youtube.FetchErrors{err, itemsErr}
+			} else if itemsErr != nil {
+				err = itemsErr
+			}
+		}
+		if len(youtube.Main.PlaylistItemResponses) == 0 || youtube.Canceled() {
+			youtube.Main.PlaylistResponses, youtube.Main.PlaylistItemResponses = oldPlaylists, oldItems
+			refreshFailed(w, r, "playlists", err)
+			return
+		}
+		catalog.Main.Refresh()
+	}
+	writeRefresh(w, r, "playlists", changed, err)
+}
+
+// UpdateAllVideosFromSheet - Refetches video responses and forces refresh
+func UpdateAllVideosFromSheet(w http.ResponseWriter, r *http.Request) {
+	catalog.Main.Lock()
+	defer catalog.Main.Unlock()
+
+	oldLen := sheets.Main.VideoLength
+
+	if err := sheets.Main.FetchVideoValues(); err != nil {
+		refreshFailed(w, r, "videos", err)
+		return
+	}
+	changed := oldLen != sheets.Main.VideoLength
+	var err error
+	if changed {
+		old := youtube.Main.VideoResponses
+		youtube.Main.VideoResponses = nil
+		if err = youtube.Main.FetchOrRead("video", true); err != nil && (len(youtube.Main.VideoResponses) == 0 || youtube.Canceled()) {
+			youtube.Main.VideoResponses = old
+			refreshFailed(w, r, "videos", err)
+			return
+		}
+		catalog.Main.Refresh()
+	}
+	writeRefresh(w, r, "videos", changed, err)
+}
+
+// Stats - Gets server statistics
+func Stats(w http.ResponseWriter, r *http.Request) {
+	render.Write(w, r, http.StatusOK, AdminStats{
+		Catalog:         catalogCounts(),
+		ShuffleSessions: ShuffleSessions.Len(),
+	})
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/compress"
+	"github.com/lemonase/youtube-meme-api/httpcache"
+	"github.com/lemonase/youtube-meme-api/render"
+)
+
+// CatalogMaxAge - How long clients may reuse responses built from the catalog without revalidating
+var CatalogMaxAge = time.Minute
+
+// snapshotETag - Returns the ETag of a catalog response, which changes with the snapshot
+// version and the negotiated representation, including the content coding since the
+// compressed bodies are different bytes
+func snapshotETag(r *http.Request, snap *catalog.Snapshot) string {
+	format := "json"
+	if f, err := render.Negotiate(r); err == nil {
+		format = f.Name
+	}
+	if !render.OptionsFromRequest(r).Pretty {
+		format += "-compact"
+	}
+	if coding := compress.Negotiate(r); coding != compress.Identity {
+		format += "-" + coding
+	}
+	return fmt.Sprintf(`"%s-%s"`, snap.Version, format)
+}
+
+// catalogNotModified - Sets the caching headers of a response built from the snapshot and
+// reports whether the client's copy is still current, in which case a 304 has been written
+func catalogNotModified(w http.ResponseWriter, r *http.Request, snap *catalog.Snapshot) bool {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(CatalogMaxAge.Seconds())))
+	httpcache.Vary(w.Header(), "Accept", "Accept-Encoding")
+	if snap.Version == "" {
+		return false
+	}
+	return httpcache.NotModified(w, r, snapshotETag(r, snap), snap.ModifiedAt)
+}
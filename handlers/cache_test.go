@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// serveList - Serves the video list of the snapshot to a GET of url with the headers
+func serveList(t *testing.T, url string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	snap := benchSnapshot(3)
+	snap.Version, snap.ModifiedAt = "v1", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	r := httptest.NewRequest(http.MethodGet, url, nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	writeList(w, r, snap, "videos", snap.VideoResponses)
+	return w
+}
+
+func TestListNotModified(t *testing.T) {
+	first := serveList(t, "/api/v1/all/video", nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get("Last-Modified") == "" {
+		t.Fatalf("status %d, ETag %q, Last-Modified %q", first.Code, etag, first.Header().Get("Last-Modified"))
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"matching etag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak matching etag", map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{"other etag", map[string]string{"If-None-Match": `"v0-json"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": "Mon, 01 Jan 2024 12:00:00 GMT"}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": "Mon, 01 Jan 2024 11:59:59 GMT"}, http.StatusOK},
+		// If-None-Match wins over If-Modified-Since
+		{"other etag and not modified since", map[string]string{"If-None-Match": `"v0-json"`, "If-Modified-Since": "Mon, 01 Jan 2024 12:00:00 GMT"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveList(t, "/api/v1/all/video", tt.headers)
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 with a %d byte body", w.Body.Len())
+			}
+		})
+	}
+}
+
+func TestListETagPerRepresentation(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		headers map[string]string
+	}{
+		{"json", "/api/v1/all/video", nil},
+		{"csv", "/api/v1/all/video?format=csv", nil},
+		{"csv by Accept", "/api/v1/all/video", map[string]string{"Accept": "text/csv"}},
+		{"compact", "/api/v1/all/video?pretty=false", nil},
+		{"gzip", "/api/v1/all/video", map[string]string{"Accept-Encoding": "gzip"}},
+		{"br", "/api/v1/all/video", map[string]string{"Accept-Encoding": "br"}},
+	}
+	etags := make(map[string]string)
+	for _, tt := range tests {
+		w := serveList(t, tt.url, tt.headers)
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || etag == "" {
+			t.Fatalf("%s: status %d, ETag %q", tt.name, w.Code, etag)
+		}
+		etags[tt.name] = etag
+
+		// the representation's own etag revalidates it
+		headers := map[string]string{"If-None-Match": etag}
+		for k, v := range tt.headers {
+			headers[k] = v
+		}
+		if w := serveList(t, tt.url, headers); w.Code != http.StatusNotModified {
+			t.Errorf("%s: revalidating with %s gave %d", tt.name, etag, w.Code)
+		}
+	}
+
+	// the same representation asked for two ways has one etag, every other one differs
+	if etags["csv"] != etags["csv by Accept"] {
+		t.Errorf("csv by format %s, by Accept %s", etags["csv"], etags["csv by Accept"])
+	}
+	seen := make(map[string]string)
+	for name, etag := range etags {
+		if name == "csv by Accept" {
+			continue
+		}
+		if other, ok := seen[etag]; ok {
+			t.Errorf("%s and %s share the ETag %s", name, other, etag)
+		}
+		seen[etag] = name
+	}
+
+	// a gzip etag does not revalidate the identity body
+	if w := serveList(t, "/api/v1/all/video", map[string]string{"If-None-Match": etags["gzip"]}); w.Code != http.StatusOK {
+		t.Errorf("identity revalidated with the gzip ETag: %d", w.Code)
+	}
+	w := serveList(t, "/api/v1/all/video", map[string]string{"Accept-Encoding": "gzip"})
+	if vary := w.Header().Values("Vary"); len(vary) != 2 || vary[0] != "Accept" || vary[1] != "Accept-Encoding" {
+		t.Errorf("Vary %v, want Accept and Accept-Encoding", vary)
+	}
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/lemonase/youtube-meme-api/catalog"
+)
+
+// Response headers with the clip of a picked video in seconds, for endpoints that return
+// YouTube responses as they are
+const (
+	StartHeader = "X-Start-Seconds"
+	EndHeader   = "X-End-Seconds"
+)
+
+// setClip - Sets the clip headers, if the entry has a clip
+func setClip(w http.ResponseWriter, e *catalog.Entry) {
+	if e.StartSeconds > 0 {
+		w.Header().Set(StartHeader, strconv.Itoa(e.StartSeconds))
+	}
+	if e.EndSeconds > 0 {
+		w.Header().Set(EndHeader, strconv.Itoa(e.EndSeconds))
+	}
+}
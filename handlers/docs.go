@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/lemonase/youtube-meme-api/api"
+	"github.com/lemonase/youtube-meme-api/apierror"
+)
+
+// DocsGroup - A heading on the docs page and its routes
+type DocsGroup struct {
+	Name   string
+	Routes []api.Route
+}
+
+// DocsData - The data that goes into the docs page
+type DocsData struct {
+	SiteTitle string
+	Groups    []DocsGroup
+}
+
+// APIHelper - Returns a handler that answers unknown API paths with a 404 listing the routes
+func APIHelper(routes []api.Route) http.HandlerFunc {
+	names, groups := api.Groups(routes)
+	endpoints := make(map[string][]string)
+	for _, name := range names {
+		for _, route := range groups[name] {
+			endpoints[name] = append(endpoints[name], route.Method+" "+route.Path)
+		}
+	}
+	details := map[string]interface{}{"endpoints": endpoints, "documentation": "/api/docs"}
+	return func(w http.ResponseWriter, r *http.Request) {
+		apierror.WriteDetails(w, r, http.StatusNotFound, "URL not found, see details for the available endpoints", details)
+	}
+}
+
+// OpenAPISpec - Returns a handler that serves an OpenAPI document
+func OpenAPISpec(doc api.Schema) http.HandlerFunc {
+	j, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		slog.Error("could not marshal OpenAPI document", "err", err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "OpenAPI document is unavailable")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(j)
+	}
+}
+
+// Docs - Returns a handler that serves the interactive documentation page
+func Docs(routes []api.Route) http.HandlerFunc {
+	names, groups := api.Groups(routes)
+	data := &DocsData{SiteTitle: SiteTitle}
+	for _, name := range names {
+		data.Groups = append(data.Groups, DocsGroup{Name: name, Routes: groups[name]})
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		tmpl, err := template.ParseFiles("html/docs.html")
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := tmpl.Execute(w, data); err != nil {
+			slog.ErrorContext(r.Context(), "could not render docs", "err", err)
+		}
+	}
+}
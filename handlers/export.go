@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/export"
+)
+
+// ExportPlaylist - Serves the catalog as a playlist for media players
+//
+//	/api/v1/export/playlist.m3u
+//	/api/v1/export/playlist.xspf
+func ExportPlaylist(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/v1/export/")
+	format, ok := export.FormatOf(name)
+	if !ok || !strings.HasPrefix(name, "playlist.") {
+		http.NotFound(w, r)
+		return
+	}
+
+	opts, err := export.OptionsFromQuery(r.URL.Query())
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if opts.Shuffle {
+		w.Header().Set(SeedHeader, strconv.FormatInt(opts.Seed, 10))
+	}
+
+	tracks := export.Tracks(snapshotOf(r), opts)
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	if err := format.Write(w, siteTitle(r), tracks); err != nil {
+		slog.ErrorContext(r.Context(), "could not write playlist", "file", name, "err", err)
+	}
+}
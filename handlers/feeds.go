@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/feeds"
+	"github.com/lemonase/youtube-meme-api/httpcache"
+)
+
+// DefaultFeedLength - Items in a feed when limit is not given
+const DefaultFeedLength = 50
+
+// MaxFeedLength - The largest allowed limit for a feed
+const MaxFeedLength = 500
+
+// baseURL - Returns the scheme and host the request was made to
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// feedETag - Changes whenever the items or their order change
+func feedETag(format string, filter catalog.Filter, entries catalog.Entries) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\n%s\n", format, filter.Key())
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s %d %s\n", e.VideoID, e.FirstSeen.UnixNano(), e.Title)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// Feed - Serves feeds of the newest videos, by when they were added to the catalog
+//
+//	/feeds/new.{atom,rss,json}
+//	/feeds/channel/{id}/new.{atom,rss,json}
+func Feed(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/feeds/")
+	filter := catalog.FilterFromQuery(r.URL.Query())
+	title := siteTitle(r) + " - New memes"
+
+	if strings.HasPrefix(rest, "channel/") {
+		parts := strings.Split(rest, "/")
+		if len(parts) != 3 || parts[1] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		filter.Channel = parts[1]
+		rest = parts[2]
+	}
+
+	ext := strings.TrimPrefix(path.Ext(rest), ".")
+	format, ok := feeds.Formats[ext]
+	if strings.TrimSuffix(rest, path.Ext(rest)) != "new" || !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	limit, ok := intParam(r, "limit", DefaultFeedLength, MaxFeedLength)
+	if !ok {
+		apierror.WriteDetails(w, r, http.StatusBadRequest, "limit must be between 1 and 500", apierror.Param("limit"))
+		return
+	}
+
+	snap := snapshotOf(r)
+	entries := snap.Newest(filter, limit)
+	if filter.Channel != "" {
+		if len(entries) == 0 {
+			apierror.Write(w, r, http.StatusNotFound, "Channel not found")
+			return
+		}
+		title = siteTitle(r) + " - New memes from " + entries[0].ChannelTitle
+	}
+
+	updated := snap.BuiltAt
+	if len(entries) > 0 {
+		updated = entries[0].FirstSeen
+	}
+	if httpcache.NotModified(w, r, feedETag(ext, filter, entries), updated) {
+		return
+	}
+
+	base := baseURL(r) + sitePath(r)
+	feed := &feeds.Feed{
+		Title:       title,
+		Description: "Memes recently added to the meme spreadsheet",
+		SiteURL:     base + "/",
+		FeedURL:     base + r.URL.RequestURI(),
+		Updated:     updated,
+	}
+	for _, e := range entries {
+		feed.Items = append(feed.Items, feeds.Item{
+			ID:        "yt:video:" + e.VideoID,
+			URL:       e.WatchURL(),
+			Title:     e.Title,
+			Summary:   e.Description,
+			Author:    e.ChannelTitle,
+			ImageURL:  e.ThumbnailURL(),
+			Published: e.PublishedAt,
+			Updated:   e.FirstSeen,
+
+			Categories: e.Tags,
+		})
+	}
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age=900")
+	if err := format.Write(w, feed); err != nil {
+		slog.ErrorContext(r.Context(), "could not write feed", "format", ext, "err", err)
+	}
+}
@@ -1,218 +1,292 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
+	"bytes"
+	"html/template"
+	"log/slog"
 	"net/http"
-	"strings"
-	"text/template"
+	"strconv"
 
-	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
 	"github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/httpcache"
+	"github.com/lemonase/youtube-meme-api/random"
+	"github.com/lemonase/youtube-meme-api/render"
 )
 
-// TemplateData - The data the goes into the served html page
+// SiteTitle - The name of the site used in page and feed titles
+var SiteTitle = "YT Meme Shuffle 🔀"
+
+// HomeTitle - The heading of the home page
+var HomeTitle = "Welcome to the Meme Shuffler"
+
+// SeedHeader - Response header that echoes the seed used for a random pick
+const SeedHeader = "X-Random-Seed"
+
+// TemplateData - The data the goes into the served html page, html/template escapes
+// the values that come from the sheet
 type TemplateData struct {
-	SiteTitle     string `json:"siteTitle"`
-	Title         string `json:"title"`
-	VideoID       string `json:"videoID"`
-	PublishedDate string `json:"publishedDate"`
+	SiteTitle     string   `json:"siteTitle"`
+	Title         string   `json:"title"`
+	VideoID       string   `json:"videoID"`
+	EmbedURL      string   `json:"embedURL"`
+	VideoTitle    string   `json:"videoTitle"`
+	Credit        string   `json:"credit"`
+	PublishedDate string   `json:"publishedDate"`
+	Seed          int64    `json:"seed"`
+	Seeded        bool     `json:"seeded"`
+	SheetURL      string   `json:"sheetURL"`
+	Tags          []string `json:"tags"`
 }
 
-// Home - Displays the home page
-func Home(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
+// randomSource - Returns a source seeded from the "seed" query parameter (or a new seed)
+// and echoes the seed back in the response headers. Random responses are never cached
+func randomSource(w http.ResponseWriter, r *http.Request) (*random.Source, bool) {
+	httpcache.NoStore(w)
+	src, err := random.FromString(r.URL.Query().Get("seed"))
+	if err != nil {
+		apierror.WriteDetails(w, r, http.StatusBadRequest, "seed must be an integer", apierror.Param("seed"))
+		return nil, false
+	}
+	w.Header().Set(SeedHeader, strconv.FormatInt(src.Seed(), 10))
+	return src, true
+}
 
-	item := youtube.GetRandomPlaylistItem()
-	id := item.ContentDetails.VideoId
-	date := item.ContentDetails.VideoPublishedAt
-	dateSlice := strings.Split(date, "-")
-	pubDate := dateSlice[0]
+// randomOptions - Returns the source and the selection strategy ("strategy" query parameter)
+// for a random pick, writing a 400 response if either is invalid
+func randomOptions(w http.ResponseWriter, r *http.Request) (*random.Source, random.Strategy, bool) {
+	src, ok := randomSource(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	strategy, err := catalogOf(r).Strategies.ByName(r.URL.Query().Get("strategy"))
+	if err != nil {
+		apierror.WriteDetails(w, r, http.StatusBadRequest, err.Error(), apierror.Param("strategy"))
+		return nil, nil, false
+	}
+	return src, strategy, true
+}
 
-	tmpl := template.Must(template.ParseFiles("html/index.html"))
+// noEntries - Writes a 404 response if the filter left nothing to pick and a 503 one
+// if nothing has been loaded
+func noEntries(w http.ResponseWriter, r *http.Request, filter catalog.Filter, loaded catalog.Entries) {
+	if !filter.IsZero() && len(loaded) > 0 {
+		apierror.Write(w, r, http.StatusNotFound, "No videos match the filter")
+		return
+	}
+	apierror.Write(w, r, http.StatusServiceUnavailable, "No videos have been loaded yet")
+}
+
+// pickEntry - Picks an entry from the list and returns it with the seed that was used,
+// writing an error response if there is nothing to pick
+func pickEntry(w http.ResponseWriter, r *http.Request, entries catalog.Entries) (*catalog.Entry, int64, bool) {
+	src, strategy, ok := randomOptions(w, r)
+	if !ok {
+		return nil, 0, false
+	}
+	filter := catalog.FilterFromQuery(r.URL.Query())
+	entry := filter.Apply(entries).Pick(src, strategy)
+	if entry == nil {
+		noEntries(w, r, filter, entries)
+		return nil, 0, false
+	}
+	countPick(entry)
+	return entry, src.Seed(), true
+}
+
+// homeTemplate - The home page, parsed once by ParseTemplates
+var homeTemplate *template.Template
+
+// ParseTemplates - Parses the page templates, called once before serving
+func ParseTemplates() error {
+	tmpl, err := template.ParseFiles("html/index.html")
+	if err != nil {
+		return err
+	}
+	homeTemplate = tmpl
+	return nil
+}
+
+// Home - Displays the home page, videos come from the client's shuffle session
+// unless a seed is requested
+func Home(w http.ResponseWriter, r *http.Request) {
+	var entry *catalog.Entry
+	var seed int64
+	var ok bool
+
+	seeded := r.URL.Query().Get("seed") != ""
+	if seeded {
+		entry, seed, ok = pickEntry(w, r, snapshotOf(r).PlaylistItems)
+	} else {
+		entry, ok = nextShuffled(w, r, snapshotOf(r).PlaylistItems)
+	}
+	if !ok {
+		return
+	}
+	if homeTemplate == nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "The home page template is not loaded")
+		return
+	}
+
+	id := entry.VideoID
 	data := &TemplateData{
-		SiteTitle:     "YT Meme Shuffle 🔀",
-		Title:         "Welcome to the Meme Shuffler",
+		SiteTitle:     siteTitle(r),
+		Title:         HomeTitle,
 		VideoID:       id,
-		PublishedDate: pubDate,
-	}
-
-	tmpl.Execute(w, data)
-}
-
-// APIHelper - Prints a helpful error message
-func APIHelper(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusNotFound)
-	fmt.Fprintln(w, "404: URL Not Found")
-	fmt.Fprintln(w, "")
-	fmt.Fprintln(w, "Endpoints are: ")
-	fmt.Fprintln(w, "	Random:")
-	fmt.Fprintln(w, "GET	/api/v1/random/video")
-	fmt.Fprintln(w, "GET	/api/v1/random/playlist")
-	fmt.Fprintln(w, "GET	/api/v1/random/playlist/item")
-	fmt.Fprintln(w, "GET	/api/v1/random/channel")
-	fmt.Fprintln(w, "")
-	fmt.Fprintln(w, "	All:")
-	fmt.Fprintln(w, "GET   	/api/v1/all/video")
-	fmt.Fprintln(w, "GET   	/api/v1/all/playlist")
-	fmt.Fprintln(w, "GET   	/api/v1/all/playlist/item")
-	fmt.Fprintln(w, "GET   	/api/v1/all/channel")
-	fmt.Fprintln(w, "")
-	fmt.Fprintln(w, "	Update:")
-	fmt.Fprintln(w, "GET   	/api/v1/update/all")
-	fmt.Fprintln(w, "GET   	/api/v1/update/video")
-	fmt.Fprintln(w, "GET   	/api/v1/update/playlist")
-	fmt.Fprintln(w, "GET   	/api/v1/update/channel")
+		EmbedURL:      entry.EmbedURL(),
+		VideoTitle:    entry.Title,
+		Credit:        entry.Credit,
+		PublishedDate: entry.DisplayDate(),
+		Seed:          seed,
+		Seeded:        seeded,
+		SheetURL:      "https://docs.google.com/spreadsheets/d/" + sheetID(r),
+		Tags:          entry.Tags,
+	}
+
+	// rendered into a buffer so a failing template still gets an error response
+	var page bytes.Buffer
+	if err := homeTemplate.Execute(&page, data); err != nil {
+		slog.ErrorContext(r.Context(), "could not render the home page", "err", err)
+		apierror.Write(w, r, http.StatusInternalServerError, "Could not render the home page")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	page.WriteTo(w)
 }
 
 // Videos
 
-// AllVideos - Get all singular videos responses
+// AllVideos - Get all singular videos responses, with only the matching videos if a filter is given
 func AllVideos(w http.ResponseWriter, r *http.Request) {
-	j, err := json.MarshalIndent(youtube.VideoResponses, "", "  ")
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatalf("Could not marshal data %v", err)
+	snap := snapshotOf(r)
+	if filter := catalog.FilterFromQuery(r.URL.Query()); !filter.IsZero() {
+		writeFilteredList(w, r, snap, snap.VideoResponsesMatching(filter))
+		return
 	}
-
-	fmt.Fprintf(w, string(j))
+	writeList(w, r, snap, "videos", snap.VideoResponses)
 }
 
 // RandomVideo - Get a random playlist item from a random playlist
 func RandomVideo(w http.ResponseWriter, r *http.Request) {
-	item := youtube.GetRandomVideo()
-
-	j, err := json.MarshalIndent(item, "", "  ")
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatalf("Could not marshal data %v", err)
+	entry, _, ok := pickEntry(w, r, snapshotOf(r).Videos)
+	if !ok {
+		return
 	}
-
-	fmt.Fprintf(w, string(j))
+	item := entry.VideoResponse
+	setTags(w, entry.Tags)
+	setClip(w, entry)
+	render.Write(w, r, http.StatusOK, item)
 }
 
 // Playlists
 
 // AllPlaylists - Get all playlist responses
 func AllPlaylists(w http.ResponseWriter, r *http.Request) {
-	j, err := json.MarshalIndent(youtube.PlaylistResponses, "", "  ")
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatalf("Could not marshal data %v", err)
-	}
-
-	fmt.Fprintf(w, string(j))
+	snap := snapshotOf(r)
+	writeList(w, r, snap, "playlists", snap.Playlists)
 }
 
-// AllPlaylistsWithItems - Get all playlist responses
+// AllPlaylistsWithItems - Get all playlist item responses, with only the matching items if a filter is given
 func AllPlaylistsWithItems(w http.ResponseWriter, r *http.Request) {
-	j, err := json.MarshalIndent(youtube.PlaylistItemResponses, "", "  ")
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatalf("Could not marshal data %v", err)
+	snap := snapshotOf(r)
+	if filter := catalog.FilterFromQuery(r.URL.Query()); !filter.IsZero() {
+		writeFilteredList(w, r, snap, snap.PlaylistItemResponsesMatching(filter))
+		return
 	}
-
-	fmt.Fprintf(w, string(j))
+	writeList(w, r, snap, "playlistItems", snap.PlaylistItemResponses)
 }
 
 // RandomPlaylist - Get a random playlist response
 func RandomPlaylist(w http.ResponseWriter, r *http.Request) {
-	randomPlaylist := youtube.GetRandomPlaylist()
-	j, err := json.MarshalIndent(randomPlaylist, "", "  ")
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatalf("Could not unmarshal %v", err)
+	src, ok := randomSource(w, r)
+	if !ok {
+		return
+	}
+	snap := snapshotOf(r)
+	playlists := snap.Playlists
+	if len(playlists) == 0 {
+		apierror.Write(w, r, http.StatusServiceUnavailable, "No playlists have been loaded yet")
+		return
+	}
+	randomPlaylist := playlists[src.Intn(len(playlists))]
+	if len(randomPlaylist.Items) > 0 {
+		randomPicks.Inc("playlist", randomPlaylist.Items[0].Id)
+		setTags(w, snap.TagsOf(randomPlaylist.Items[0].Id))
 	}
-	fmt.Fprintf(w, string(j))
+	render.Write(w, r, http.StatusOK, randomPlaylist)
 }
 
 // RandomPlaylistItem - Get a random playlist response
 func RandomPlaylistItem(w http.ResponseWriter, r *http.Request) {
-	item := youtube.GetRandomPlaylistItem()
-	j, err := json.MarshalIndent(item, "", "  ")
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatalf("Could not unmarshal %v", err)
+	entry, _, ok := pickEntry(w, r, snapshotOf(r).PlaylistItems)
+	if !ok {
+		return
 	}
-	fmt.Fprintf(w, string(j))
+	item := entry.PlaylistItem
+	setTags(w, entry.Tags)
+	setClip(w, entry)
+	render.Write(w, r, http.StatusOK, item)
 }
 
 // Channels
 
 // AllChannels - Get all youtube channel responses
 func AllChannels(w http.ResponseWriter, r *http.Request) {
-	j, err := json.MarshalIndent(youtube.ChannelResponses, "", "  ")
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatalf("Could not marshal data %v", err)
-	}
-
-	fmt.Fprintf(w, string(j))
+	snap := snapshotOf(r)
+	writeList(w, r, snap, "channels", snap.Channels)
 }
 
 // RandomChannel - Get a random channel from youtube responses
 func RandomChannel(w http.ResponseWriter, r *http.Request) {
-	randomChannel := youtube.GetRandomChannel()
-	j, err := json.MarshalIndent(randomChannel, "", "  ")
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatalf("Could not unmarshal %v", err)
+	src, ok := randomSource(w, r)
+	if !ok {
+		return
 	}
-	fmt.Fprintf(w, string(j))
-}
-
-// FetchAllYoutubeInfoFromSheet - Gets sheet values, resets responses and fetches youtube data
-func FetchAllYoutubeInfoFromSheet(forceRefresh bool) {
-	sheets.FetchAllValues()
-	youtube.ChannelResponses, youtube.PlaylistResponses, youtube.VideoResponses = nil, nil, nil
-	youtube.FetchOrReadAll(forceRefresh)
-}
-
-// UpdateAllValuesFromSheet - Updates json files by enforcing refresh
-func UpdateAllValuesFromSheet(w http.ResponseWriter, r *http.Request) {
-	FetchAllYoutubeInfoFromSheet(true)
-}
-
-// UpdateAllChannelsFromSheet - Refetches channel responses and forces refresh
-func UpdateAllChannelsFromSheet(w http.ResponseWriter, r *http.Request) {
-	oldLen := sheets.ChannelLength
-
-	sheets.FetchChannelValues()
-	if oldLen != sheets.ChannelLength {
-		youtube.ChannelResponses = nil
-		youtube.FetchOrRead("channel", true)
+	snap := snapshotOf(r)
+	channels := snap.Channels
+	if len(channels) == 0 {
+		apierror.Write(w, r, http.StatusServiceUnavailable, "No channels have been loaded yet")
+		return
 	}
+	randomChannel := channels[src.Intn(len(channels))]
+	if len(randomChannel.Items) > 0 {
+		randomPicks.Inc("channel", randomChannel.Items[0].Id)
+		if details := randomChannel.Items[0].ContentDetails; details != nil && details.RelatedPlaylists != nil {
+			setTags(w, snap.TagsOf(details.RelatedPlaylists.Uploads))
+		}
+	}
+	render.Write(w, r, http.StatusOK, randomChannel)
 }
 
-// UpdateAllPlaylistsFromSheet - Refetches playlist responses and forces refresh
-func UpdateAllPlaylistsFromSheet(w http.ResponseWriter, r *http.Request) {
-	oldLen := sheets.PlaylistLength
+// FetchAllYoutubeInfoFromSheet - Gets sheet values, resets responses and fetches youtube data.
+// If the sheet or no videos could be loaded (or the fetch was cancelled) the previous responses
+// are kept, otherwise the catalog is refreshed with what was loaded and the errors of the rest are returned
+func FetchAllYoutubeInfoFromSheet(forceRefresh bool) error {
+	return fetchAllFromSheet(catalog.Main, forceRefresh)
+}
 
-	sheets.FetchPlaylistValues()
-	if oldLen != sheets.PlaylistLength {
-		youtube.PlaylistResponses = nil
-		youtube.PlaylistItemResponses = nil
-		youtube.FetchOrRead("playlist", true)
-		youtube.FetchOrRead("playlistItem", true)
+// fetchAllFromSheet - Like FetchAllYoutubeInfoFromSheet for any catalog, the caller has to hold its lock
+func fetchAllFromSheet(c *catalog.Catalog, forceRefresh bool) error {
+	sheet, store := c.Sheet, c.YouTube
+	if err := sheet.FetchAllValues(); err != nil {
+		slog.Error("could not fetch sheet values", "sheet", sheet.SheetID, "err", err)
+		// without any rows every cache file would be overwritten with nothing
+		if sheet.VideoLength+sheet.PlaylistLength+sheet.ChannelLength == 0 {
+			return err
+		}
 	}
-}
 
-// UpdateAllVideosFromSheet - Refetches video responses and forces refresh
-func UpdateAllVideosFromSheet(w http.ResponseWriter, r *http.Request) {
-	oldLen := sheets.VideoLength
+	channels, playlists, items, videos := store.ChannelResponses, store.PlaylistResponses, store.PlaylistItemResponses, store.VideoResponses
+	store.ChannelResponses, store.PlaylistResponses, store.VideoResponses = nil, nil, nil
+	store.PlaylistItemResponses = nil
 
-	sheets.FetchVideoValues()
-	if oldLen != sheets.VideoLength {
-		youtube.VideoResponses = nil
-		youtube.FetchOrRead("video", true)
+	err := store.FetchOrReadAll(forceRefresh)
+	if err != nil && (len(store.VideoResponses) == 0 && len(store.PlaylistItemResponses) == 0 || youtube.Canceled()) {
+		store.ChannelResponses, store.PlaylistResponses, store.PlaylistItemResponses, store.VideoResponses = channels, playlists, items, videos
+		return err
 	}
+	c.Refresh()
+	return err
 }
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	"github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/auth"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/render"
+)
+
+// Sheets webhook headers
+const (
+	HookTimestampHeader = "X-Hook-Timestamp"
+	HookSignatureHeader = "X-Hook-Signature"
+)
+
+// SheetsHookSecret - The secret that /hooks/sheets payloads are signed with, the hook is disabled if empty
+var SheetsHookSecret string
+
+// SheetsHookDelay - How long after the last edit the queued edits are applied
+var SheetsHookDelay = 5 * time.Second
+
+// SheetsHookMaxDelay - The longest an edit waits while edits keep coming in
+var SheetsHookMaxDelay = 30 * time.Second
+
+// maxHookBody - The largest webhook payload that is accepted
+const maxHookBody = 1 << 20
+
+// SheetEdit - A changed cell, rows and columns are numbered from 1 like in Apps Script
+type SheetEdit struct {
+	Row      int    `json:"row"`
+	Column   int    `json:"column"`
+	OldValue string `json:"oldValue,omitempty"`
+	NewValue string `json:"newValue"`
+}
+
+// SheetEdits - The payload sent by the Apps Script trigger in scripts/sheets-webhook.gs
+type SheetEdits struct {
+	// Sheet - the name of the edited sheet (tab)
+	Sheet string      `json:"sheet"`
+	Edits []SheetEdit `json:"edits"`
+}
+
+// HookResponse - The response to an accepted webhook
+type HookResponse struct {
+	// Queued - the number of edits in the payload that touch columns the server reads
+	Queued int `json:"queued"`
+	// Pending - the number of cells waiting to be applied
+	Pending int `json:"pending"`
+}
+
+// hookSignature - Returns the hex HMAC-SHA256 of "timestamp.body"
+func hookSignature(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var hookReplays = auth.NewReplayGuard()
+
+// SheetsHook - Receives signed cell edits from the sheet and queues them to be applied
+func SheetsHook(w http.ResponseWriter, r *http.Request) {
+	if SheetsHookSecret == "" {
+		apierror.Write(w, r, http.StatusNotFound, "The sheets webhook is not configured on this server")
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBody))
+	if err != nil {
+		apierror.Write(w, r, http.StatusRequestEntityTooLarge, "Payload is too large")
+		return
+	}
+
+	now := time.Now()
+	timestamp := r.Header.Get(HookTimestampHeader)
+	if err := auth.CheckTimestamp(timestamp, now); err != nil {
+		apierror.Write(w, r, http.StatusUnauthorized, "Invalid "+HookTimestampHeader+": "+err.Error())
+		return
+	}
+	signature := auth.NormalizeSignature(r.Header.Get(HookSignatureHeader))
+	if !hmac.Equal([]byte(hookSignature(SheetsHookSecret, timestamp, body)), []byte(signature)) {
+		apierror.Write(w, r, http.StatusUnauthorized, "Invalid "+HookSignatureHeader)
+		return
+	}
+	if !hookReplays.Check(signature, now) {
+		apierror.Write(w, r, http.StatusUnauthorized, "Payload was already delivered")
+		return
+	}
+
+	var payload SheetEdits
+	if err := json.Unmarshal(body, &payload); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "Payload must be JSON like {\"sheet\": \"Sheet1\", \"edits\": [{\"row\": 2, \"column\": 1, \"newValue\": \"...\"}]}")
+		return
+	}
+
+	queued := 0
+	for _, e := range payload.Edits {
+		if _, _, ok := sheets.Main.Locate(payload.Sheet, e.Row, e.Column); ok {
+			queued++
+		}
+	}
+	pending := sheetEdits.add(payload.Sheet, payload.Edits)
+	render.Write(w, r, http.StatusAccepted, HookResponse{Queued: queued, Pending: pending})
+}
+
+// cell - The position of an edited cell
+type cell struct {
+	sheet       string
+	row, column int
+}
+
+// editQueue - Collects edits and applies them once no edits came in for a while,
+// so a burst of typing or a paste only refreshes once. Later edits of a cell replace earlier ones
+type editQueue struct {
+	mu    sync.Mutex
+	order []cell
+	value map[cell]string
+	timer *time.Timer
+	first time.Time
+	apply func(map[cell]string, []cell)
+}
+
+var sheetEdits = &editQueue{apply: applySheetEdits}
+
+// add - Queues edits and (re)starts the timer, returns the number of pending cells
+func (q *editQueue) add(sheet string, edits []SheetEdit) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.value == nil {
+		q.value = make(map[cell]string)
+	}
+
+	for _, e := range edits {
+		if _, _, ok := sheets.Main.Locate(sheet, e.Row, e.Column); !ok {
+			continue
+		}
+		c := cell{sheet, e.Row, e.Column}
+		if _, ok := q.value[c]; !ok {
+			q.order = append(q.order, c)
+		}
+		q.value[c] = e.NewValue
+	}
+	if len(q.order) == 0 {
+		return 0
+	}
+
+	now := time.Now()
+	if q.timer == nil {
+		q.first = now
+		q.timer = time.AfterFunc(SheetsHookDelay, q.flush)
+	} else {
+		delay := SheetsHookDelay
+		if wait := q.first.Add(SheetsHookMaxDelay).Sub(now); wait < delay {
+			delay = wait
+		}
+		q.timer.Reset(delay)
+	}
+	return len(q.order)
+}
+
+// stop - Stops the timer and drops the queued edits, returns the number of dropped cells
+func (q *editQueue) stop() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.timer != nil {
+		q.timer.Stop()
+	}
+	dropped := len(q.order)
+	q.order, q.value, q.timer = nil, nil, nil
+	return dropped
+}
+
+// flush - Applies the queued edits
+func (q *editQueue) flush() {
+	q.mu.Lock()
+	order, value := q.order, q.value
+	q.order, q.value, q.timer = nil, nil, nil
+	q.mu.Unlock()
+
+	if len(order) > 0 {
+		q.apply(value, order)
+	}
+}
+
+// applySheetEdits - Updates the sheet values and refetches the YouTube data of the edited rows.
+// If a row can not be updated on its own, everything is refetched instead
+func applySheetEdits(value map[cell]string, order []cell) {
+	catalog.Main.Lock()
+	defer catalog.Main.Unlock()
+
+	slog.Info("applying sheet edits", "edits", len(order))
+	for _, c := range order {
+		column, row, ok := sheets.Main.Locate(c.sheet, c.row, c.column)
+		if !ok {
+			continue
+		}
+		oldValue := sheets.CellString(*column.Values, row)
+		newValue := value[c]
+		if oldValue == newValue {
+			continue
+		}
+
+		var err error
+		switch column.Name {
+		case "videos":
+			err = youtube.Main.SetVideo(oldValue, newValue)
+		case "playlists":
+			err = youtube.Main.SetPlaylist(oldValue, newValue)
+		case "channels":
+			err = youtube.Main.SetChannel(oldValue, newValue)
+		}
+		if err != nil && youtube.Canceled() {
+			slog.Warn("stopped applying sheet edits for shutdown", "edits", len(order))
+			return
+		}
+		if err != nil {
+			slog.Warn("could not update row, refetching everything", "column", column.Name, "row", c.row, "err", err)
+			if err := FetchAllYoutubeInfoFromSheet(true); err != nil {
+				slog.Error("could not refetch after sheet edits", "err", err)
+			}
+			return
+		}
+		column.SetCell(row, newValue)
+		slog.Info("updated row", "column", column.Name, "row", c.row)
+	}
+
+	if err := youtube.Main.SaveAll(); err != nil {
+		slog.Error("could not save responses", "err", err)
+	}
+	catalog.Main.Refresh()
+}
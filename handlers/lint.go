@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/httpcache"
+	"github.com/lemonase/youtube-meme-api/lint"
+	"github.com/lemonase/youtube-meme-api/render"
+	"github.com/lemonase/youtube-meme-api/tenant"
+)
+
+// LintData - The data that goes into the lint report page
+type LintData struct {
+	SiteTitle string
+	Tenant    string
+	Report    lint.Report
+}
+
+// LintSheet - Checks the loaded sheet values and YouTube responses of a tenant,
+// or of the main catalog if t is nil
+func LintSheet(t *tenant.Tenant) lint.Report {
+	c := catalog.Main
+	if t != nil {
+		c = t.Catalog
+	}
+	c.Lock()
+	defer c.Unlock()
+	return lint.Check(c.Sheet, c.YouTube)
+}
+
+// wantsHTML - Reports whether the client asked for an HTML page with ?format=html, or
+// prefers it in the Accept header like browsers do
+func wantsHTML(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "html"
+	}
+	return strings.HasPrefix(r.Header.Get("Accept"), "text/html")
+}
+
+// SheetLint - Reports unparseable, duplicate, misplaced and unresolvable cells of the sheet,
+// as an HTML table for browsers
+//
+//	/api/admin/sheet/lint
+//	/api/admin/sheet/lint?tenant={id}
+func SheetLint(w http.ResponseWriter, r *http.Request) {
+	httpcache.NoStore(w)
+	var t *tenant.Tenant
+	if id := r.URL.Query().Get("tenant"); id != "" {
+		if t = tenant.Get(id); t == nil {
+			apierror.WriteDetails(w, r, http.StatusNotFound, "Tenant not found", apierror.Param("tenant"))
+			return
+		}
+	}
+	report := LintSheet(t)
+
+	if !wantsHTML(r) {
+		render.Write(w, r, http.StatusOK, report)
+		return
+	}
+	tmpl, err := template.ParseFiles("html/lint.html")
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	data := LintData{SiteTitle: SiteTitle, Report: report}
+	if t != nil {
+		data.Tenant = t.ID
+		if t.Title != "" {
+			data.SiteTitle = t.Title
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		slog.ErrorContext(r.Context(), "could not render lint report", "err", err)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/render"
+)
+
+// MaxBatchGetIDs - The most IDs a single batchGet request may ask for
+const MaxBatchGetIDs = 100
+
+// BatchGetRequest - Body of a POST to /api/v1/videos:batchGet
+type BatchGetRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// BatchGetResponse - Videos found for a batchGet request, in the order they were asked for
+type BatchGetResponse struct {
+	Videos   []*catalog.Entry `json:"videos"`
+	NotFound []string         `json:"notFound"`
+}
+
+// pathID - Returns the path segment after prefix and any remaining path after it
+func pathID(r *http.Request, prefix string) (id string, rest string) {
+	path := strings.TrimPrefix(r.URL.Path, prefix)
+	if i := strings.Index(path, "/"); i >= 0 {
+		return path[:i], path[i:]
+	}
+	return path, ""
+}
+
+// VideoByID - Get a single video from the catalog (/api/v1/video/{id})
+func VideoByID(w http.ResponseWriter, r *http.Request) {
+	id, rest := pathID(r, "/api/v1/video/")
+	snap := snapshotOf(r)
+	entry := snap.Video(id)
+	if id == "" || rest != "" || entry == nil {
+		apierror.Write(w, r, http.StatusNotFound, "Video not found")
+		return
+	}
+	if catalogNotModified(w, r, snap) {
+		return
+	}
+	render.Write(w, r, http.StatusOK, entry)
+}
+
+// PlaylistByID - Get a single playlist (/api/v1/playlist/{id})
+// or its videos (/api/v1/playlist/{id}/items) from the catalog
+func PlaylistByID(w http.ResponseWriter, r *http.Request) {
+	id, rest := pathID(r, "/api/v1/playlist/")
+	snap := snapshotOf(r)
+	playlist := snap.Playlist(id)
+	if id == "" || playlist == nil || (rest != "" && rest != "/items") {
+		apierror.Write(w, r, http.StatusNotFound, "Playlist not found")
+		return
+	}
+	if catalogNotModified(w, r, snap) {
+		return
+	}
+
+	if rest == "/items" {
+		items := snap.PlaylistItemsOf(id)
+		if items == nil {
+			// an empty playlist is an empty list, not null
+			items = catalog.Entries{}
+		}
+		render.Write(w, r, http.StatusOK, items)
+		return
+	}
+	render.Write(w, r, http.StatusOK, playlist)
+}
+
+// ChannelByID - Get a single channel from the catalog (/api/v1/channel/{id})
+func ChannelByID(w http.ResponseWriter, r *http.Request) {
+	id, rest := pathID(r, "/api/v1/channel/")
+	snap := snapshotOf(r)
+	channel := snap.Channel(id)
+	if id == "" || rest != "" || channel == nil {
+		apierror.Write(w, r, http.StatusNotFound, "Channel not found")
+		return
+	}
+	if catalogNotModified(w, r, snap) {
+		return
+	}
+	render.Write(w, r, http.StatusOK, channel)
+}
+
+// BatchGetVideos - Get up to MaxBatchGetIDs videos from the catalog in one request
+func BatchGetVideos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "Method not allowed, use POST")
+		return
+	}
+
+	var req BatchGetRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "Could not decode request body: "+err.Error())
+		return
+	}
+	if len(req.IDs) > MaxBatchGetIDs {
+		apierror.WriteDetails(w, r, http.StatusBadRequest, "Too many ids, the limit is 100", map[string]int{"limit": MaxBatchGetIDs, "received": len(req.IDs)})
+		return
+	}
+
+	snap := snapshotOf(r)
+	res := BatchGetResponse{Videos: []*catalog.Entry{}, NotFound: []string{}}
+	for _, id := range req.IDs {
+		if entry := snap.Video(id); entry != nil {
+			res.Videos = append(res.Videos, entry)
+		} else {
+			res.NotFound = append(res.NotFound, id)
+		}
+	}
+	render.Write(w, r, http.StatusOK, res)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/metrics"
+)
+
+var randomPicks = metrics.NewCounterVec("random_picks_total",
+	"Random and shuffle picks by kind (video, playlistItem, playlist or channel) and source playlist, channel or video ID", "kind", "source")
+
+var _ = metrics.NewGaugeFunc("catalog_items", "Number of items in the catalog by type", func() []metrics.Sample {
+	counts := catalogCounts()
+	return []metrics.Sample{
+		{LabelValues: []string{"video"}, Value: float64(counts.Videos)},
+		{LabelValues: []string{"playlistItem"}, Value: float64(counts.PlaylistItems)},
+		{LabelValues: []string{"playlist"}, Value: float64(counts.Playlists)},
+		{LabelValues: []string{"channel"}, Value: float64(counts.Channels)},
+	}
+}, "type")
+
+var _ = metrics.NewGaugeFunc("catalog_modified_timestamp_seconds", "Unix time the catalog version last changed", func() []metrics.Sample {
+	modified := catalog.Current().ModifiedAt
+	if modified.IsZero() {
+		return nil
+	}
+	return []metrics.Sample{{Value: float64(modified.UnixNano()) / 1e9}}
+})
+
+// countPick - Counts a picked catalog entry by its source
+func countPick(entry *catalog.Entry) {
+	if entry.Video != nil {
+		randomPicks.Inc("video", entry.Source)
+	} else {
+		randomPicks.Inc("playlistItem", entry.Source)
+	}
+}
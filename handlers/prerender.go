@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/compress"
+	"github.com/lemonase/youtube-meme-api/render"
+)
+
+// renderedKey - A list endpoint response in one representation
+type renderedKey struct {
+	list   string
+	format string
+	pretty bool
+}
+
+// renderedBody - A body that is built on first use
+type renderedBody struct {
+	once sync.Once
+	body *compress.Body
+	err  error
+}
+
+// renderedSnapshot - The bodies built from one snapshot
+type renderedSnapshot struct {
+	snap   *catalog.Snapshot
+	bodies map[renderedKey]*renderedBody
+}
+
+// renderedCache - Encoded and compressed list responses of the current snapshot of every
+// catalog by site path, so serving the large /all lists does not encode the whole catalog on
+// every request, and requests for one tenant do not throw away the bodies of another
+type renderedCache struct {
+	mu    sync.Mutex
+	sites map[string]*renderedSnapshot
+}
+
+var rendered = &renderedCache{sites: make(map[string]*renderedSnapshot)}
+
+// get - Returns the body for a key, building it at most once per snapshot of a site
+func (c *renderedCache) get(site string, snap *catalog.Snapshot, key renderedKey, f render.Format, opts render.Options, v interface{}) (*compress.Body, error) {
+	c.mu.Lock()
+	rs := c.sites[site]
+	if rs == nil || rs.snap != snap {
+		rs = &renderedSnapshot{snap: snap, bodies: make(map[renderedKey]*renderedBody)}
+		c.sites[site] = rs
+	}
+	rb, ok := rs.bodies[key]
+	if !ok {
+		rb = &renderedBody{}
+		rs.bodies[key] = rb
+	}
+	c.mu.Unlock()
+
+	rb.once.Do(func() {
+		var data []byte
+		if data, rb.err = render.Render(f, v, opts); rb.err == nil {
+			rb.body = compress.NewBody(f.ContentType, data)
+		}
+	})
+	return rb.body, rb.err
+}
+
+// drop - Forgets the bodies of a site, for tenants that were removed
+func (c *renderedCache) drop(site string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sites, site)
+}
+
+// writeFilteredList - Serves a list built for one request, with the snapshot's caching headers
+func writeFilteredList(w http.ResponseWriter, r *http.Request, snap *catalog.Snapshot, v interface{}) {
+	if catalogNotModified(w, r, snap) {
+		return
+	}
+	render.Write(w, r, http.StatusOK, v)
+}
+
+// writeList - Serves a list of the snapshot from the rendered cache, with caching headers
+func writeList(w http.ResponseWriter, r *http.Request, snap *catalog.Snapshot, list string, v interface{}) {
+	if catalogNotModified(w, r, snap) {
+		return
+	}
+	f, err := render.Negotiate(r)
+	if err != nil {
+		apierror.WriteDetails(w, r, http.StatusNotAcceptable, err.Error(), apierror.Param("format"))
+		return
+	}
+	opts := render.OptionsFromRequest(r)
+
+	body, err := rendered.get(sitePath(r), snap, renderedKey{list, f.Name, opts.Pretty}, f, opts, v)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Could not encode "+list)
+		return
+	}
+	body.Serve(w, r, http.StatusOK)
+}
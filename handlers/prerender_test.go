@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/compress"
+	"github.com/lemonase/youtube-meme-api/render"
+	"google.golang.org/api/youtube/v3"
+)
+
+// benchSnapshot - A snapshot with n video responses, about the size of a real sheet
+func benchSnapshot(n int) *catalog.Snapshot {
+	snap := &catalog.Snapshot{BuiltAt: time.Now()}
+	for i := 0; i < n; i++ {
+		snap.VideoResponses = append(snap.VideoResponses, &youtube.VideoListResponse{
+			Items: []*youtube.Video{{
+				Id: fmt.Sprintf("video%06d", i),
+				Snippet: &youtube.VideoSnippet{
+					Title:        fmt.Sprintf("Meme number %d", i),
+					Description:  "A video from the sheet with a description that is a bit longer than the title",
+					ChannelId:    "UCabcdefghijklmnopqrstuv",
+					ChannelTitle: "Some channel",
+					PublishedAt:  "2019-03-12T00:00:00Z",
+				},
+				ContentDetails: &youtube.VideoContentDetails{Duration: "PT1M30S"},
+			}},
+		})
+	}
+	return snap
+}
+
+// BenchmarkAllVideos - Serving /all/video from the pre-rendered bodies against encoding and
+// compressing it on every request
+func BenchmarkAllVideos(b *testing.B) {
+	snap := benchSnapshot(1000)
+	for _, coding := range []string{compress.Identity, compress.Gzip, compress.Brotli} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/all/video", nil)
+		r.Header.Set("Accept-Encoding", coding)
+
+		b.Run("prerendered/"+coding, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				writeList(httptest.NewRecorder(), r, snap, "videos", snap.VideoResponses)
+			}
+		})
+		b.Run("perRequest/"+coding, func(b *testing.B) {
+			h := compress.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				render.Write(w, r, http.StatusOK, snap.VideoResponses)
+			}))
+			for i := 0; i < b.N; i++ {
+				h.ServeHTTP(httptest.NewRecorder(), r)
+			}
+		})
+	}
+}
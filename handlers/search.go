@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/render"
+)
+
+// DefaultSearchPageSize - Results per page when pageSize is not given
+const DefaultSearchPageSize = 20
+
+// MaxSearchPageSize - The largest allowed pageSize
+const MaxSearchPageSize = 100
+
+// SearchResponse - A page of search results
+type SearchResponse struct {
+	Query    string                  `json:"query"`
+	Total    int                     `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"pageSize"`
+	Results  []*catalog.SearchResult `json:"results"`
+}
+
+// intParam - Reads a positive integer query parameter, returning def if it is not set
+func intParam(r *http.Request, name string, def int, max int) (int, bool) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || (max > 0 && n > max) {
+		return 0, false
+	}
+	return n, true
+}
+
+// Search - Full text search over video and playlist titles, descriptions, channel names and tags.
+// With random=true a single random result is returned instead of a page
+func Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := q.Get("q")
+	if query == "" {
+		apierror.WriteDetails(w, r, http.StatusBadRequest, "Missing search query, use ?q=", apierror.Param("q"))
+		return
+	}
+	kind := q.Get("type")
+	if kind != "" && kind != "video" && kind != "playlist" {
+		apierror.WriteDetails(w, r, http.StatusBadRequest, "type must be video or playlist", apierror.Param("type"))
+		return
+	}
+
+	results := snapshotOf(r).Search(query, kind)
+
+	if random, _ := strconv.ParseBool(q.Get("random")); random {
+		src, ok := randomSource(w, r)
+		if !ok {
+			return
+		}
+		if len(results) == 0 {
+			apierror.Write(w, r, http.StatusNotFound, "No results for "+strconv.Quote(query))
+			return
+		}
+		render.Write(w, r, http.StatusOK, results[src.Intn(len(results))].Highlight(query))
+		return
+	}
+
+	page, ok := intParam(r, "page", 1, 0)
+	if !ok {
+		apierror.WriteDetails(w, r, http.StatusBadRequest, "page must be a positive integer", apierror.Param("page"))
+		return
+	}
+	pageSize, ok := intParam(r, "pageSize", DefaultSearchPageSize, MaxSearchPageSize)
+	if !ok {
+		apierror.WriteDetails(w, r, http.StatusBadRequest, "pageSize must be between 1 and 100", apierror.Param("pageSize"))
+		return
+	}
+
+	res := SearchResponse{
+		Query:    query,
+		Total:    len(results),
+		Page:     page,
+		PageSize: pageSize,
+		Results:  []*catalog.SearchResult{},
+	}
+	// pages past the end are empty, checked before multiplying so a huge page can not overflow
+	if page-1 < (len(results)+pageSize-1)/pageSize {
+		start := (page - 1) * pageSize
+		end := start + pageSize
+		if end > len(results) {
+			end = len(results)
+		}
+		for _, result := range results[start:end] {
+			res.Results = append(res.Results, result.Highlight(query))
+		}
+	}
+	render.Write(w, r, http.StatusOK, res)
+}
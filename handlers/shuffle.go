@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/httpcache"
+	"github.com/lemonase/youtube-meme-api/random"
+	"github.com/lemonase/youtube-meme-api/render"
+)
+
+// ShuffleCookie - Cookie that holds a client's shuffle session token
+const ShuffleCookie = "shuffle_session"
+
+// ShuffleHeader - Response header that echoes the shuffle session token
+const ShuffleHeader = "X-Shuffle-Session"
+
+// ShuffleSessions - Server side shuffle bags, so a client sees every video once before repeats
+var ShuffleSessions = random.NewShuffleSessions(30*time.Minute, 10000)
+
+// shuffleToken - Returns the session token from the "session" query parameter or the cookie
+func shuffleToken(r *http.Request) string {
+	if token := r.URL.Query().Get("session"); token != "" {
+		return token
+	}
+	if cookie, err := r.Cookie(ShuffleCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// nextShuffled - Returns the next entry in the client's shuffle session,
+// writing a 503 response if there is nothing to pick. Shuffled responses are never cached
+func nextShuffled(w http.ResponseWriter, r *http.Request, entries catalog.Entries) (*catalog.Entry, bool) {
+	httpcache.NoStore(w)
+	filter := catalog.FilterFromQuery(r.URL.Query())
+	loaded := entries
+	entries = filter.Apply(entries)
+
+	// the session starts over when the client switches tenants
+	token, index := ShuffleSessions.Next(shuffleToken(r), len(entries), sitePath(r)+filter.Key())
+	w.Header().Set(ShuffleHeader, token)
+	http.SetCookie(w, &http.Cookie{
+		Name:     ShuffleCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(ShuffleSessions.TTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if index < 0 {
+		noEntries(w, r, filter, loaded)
+		return nil, false
+	}
+	countPick(entries[index])
+	return entries[index], true
+}
+
+// ShufflePlaylistItem - Get the next playlist item in the client's shuffle session
+func ShufflePlaylistItem(w http.ResponseWriter, r *http.Request) {
+	entry, ok := nextShuffled(w, r, snapshotOf(r).PlaylistItems)
+	if !ok {
+		return
+	}
+	setTags(w, entry.Tags)
+	setClip(w, entry)
+	render.Write(w, r, http.StatusOK, entry.PlaylistItem)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/tenant"
+)
+
+// LoadingRetryAfter - Seconds clients are asked to wait while the catalog is loading
+const LoadingRetryAfter = "5"
+
+// loading - 1 until the initial fetch finished
+var loading int32 = 1
+
+// Loading - Reports whether the initial fetch is still running
+func Loading() bool {
+	return atomic.LoadInt32(&loading) == 1
+}
+
+// LoadCached - Builds the catalog from the responses saved by the last run without calling
+// any API, so a starting server can serve them until the initial fetch is done
+func LoadCached() {
+	catalog.Main.Lock()
+	defer catalog.Main.Unlock()
+
+	if err := youtube.Main.ReadCached(); err != nil {
+		slog.Warn("could not read cached responses", "err", err)
+	}
+	if len(youtube.Main.VideoResponses) == 0 && len(youtube.Main.PlaylistItemResponses) == 0 {
+		slog.Info("no cached responses, the catalog is served once it is fetched")
+		return
+	}
+	snap := catalog.Main.Refresh()
+	slog.Info("serving cached catalog", "videos", len(snap.Videos), "playlistItems", len(snap.PlaylistItems))
+}
+
+// InitialFetch - Fetches the sheet and the YouTube data the sheet refers to (from the cache files
+// where they exist) and marks the initial load as done, even if it failed
+func InitialFetch() error {
+	catalog.Main.Lock()
+	defer catalog.Main.Unlock()
+	defer atomic.StoreInt32(&loading, 0)
+
+	return FetchAllYoutubeInfoFromSheet(false)
+}
+
+// ScheduledRefresh - Refetches the sheet and every YouTube response, run by the refresh schedule
+func ScheduledRefresh() {
+	catalog.Main.Lock()
+	defer catalog.Main.Unlock()
+
+	slog.Info("scheduled refresh started")
+	if err := FetchAllYoutubeInfoFromSheet(true); err != nil {
+		slog.Error("scheduled refresh failed", "err", err)
+		return
+	}
+	slog.Info("scheduled refresh done")
+}
+
+// WaitForCatalog - Answers 503 with a Retry-After header while the initial fetch is running
+// and there is no cached catalog to serve
+func WaitForCatalog(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if catalogLoading(r) && !ready(snapshotOf(r)) {
+			w.Header().Set("Retry-After", LoadingRetryAfter)
+			apierror.Write(w, r, http.StatusServiceUnavailable, "The catalog is still loading, try again in a few seconds")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Shutdown - Drops the sheet edits that were not applied yet and waits until the running
+// refreshes of the main and tenant catalogs (cancelled with client.Cancel) have stopped
+// writing, or until ctx is done. No refresh can start afterwards
+func Shutdown(ctx context.Context) error {
+	if dropped := sheetEdits.stop(); dropped > 0 {
+		slog.Warn("dropped sheet edits that were not applied, refresh after the restart to apply them", "edits", dropped)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		catalog.Main.Lock()
+		for _, t := range tenant.All() {
+			t.Catalog.Lock()
+		}
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/httpcache"
+	"github.com/lemonase/youtube-meme-api/render"
+	"github.com/lemonase/youtube-meme-api/status"
+)
+
+// Health - The body of the health and readiness checks
+type Health struct {
+	Status string `json:"status"`
+}
+
+// CacheFile - A file the responses of a type are stored in
+type CacheFile struct {
+	Path       string     `json:"path"`
+	Exists     bool       `json:"exists"`
+	Size       int64      `json:"size,omitempty"`
+	ModifiedAt *time.Time `json:"modifiedAt,omitempty"`
+	AgeSeconds int64      `json:"ageSeconds,omitempty"`
+}
+
+// ServerStatus - The state of the catalog and of the refreshes it is built from
+type ServerStatus struct {
+	Version       string        `json:"version"`
+	StartedAt     time.Time     `json:"startedAt"`
+	UptimeSeconds int64         `json:"uptimeSeconds"`
+	Ready         bool          `json:"ready"`
+	Loading       bool          `json:"loading"`
+	Catalog       CatalogCounts `json:"catalog"`
+	// CatalogVersion - the version the list endpoints send in their ETags
+	CatalogVersion    string    `json:"catalogVersion,omitempty"`
+	CatalogModifiedAt time.Time `json:"catalogModifiedAt"`
+	// Refreshes - the last successful load and the last error of the sheet and every response type
+	Refreshes  map[string]status.Refresh `json:"refreshes"`
+	LastError  *status.Error             `json:"lastError,omitempty"`
+	CacheFiles map[string]CacheFile      `json:"cacheFiles"`
+}
+
+// ready - Reports whether the catalog has been loaded and has something to play
+func ready(snap *catalog.Snapshot) bool {
+	return len(snap.Videos)+len(snap.PlaylistItems) > 0
+}
+
+// cacheFiles - Returns the cache file of every response type in a data directory
+func cacheFiles(dir string, now time.Time) map[string]CacheFile {
+	files := make(map[string]CacheFile)
+	for pageType, path := range youtube.CacheFilesIn(dir) {
+		f := CacheFile{Path: path}
+		if info, err := os.Stat(path); err == nil {
+			modified := info.ModTime()
+			f.Exists, f.Size, f.ModifiedAt = true, info.Size(), &modified
+			f.AgeSeconds = int64(now.Sub(modified).Seconds())
+		}
+		files[pageType] = f
+	}
+	return files
+}
+
+// Healthz - Answers 200 as long as the process is serving requests
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	httpcache.NoStore(w)
+	render.Write(w, r, http.StatusOK, Health{Status: "ok"})
+}
+
+// Readyz - Answers 200 once the catalog is loaded and not empty, 503 before that
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	httpcache.NoStore(w)
+	if !ready(catalog.Current()) {
+		apierror.Write(w, r, http.StatusServiceUnavailable, "The catalog has not been loaded yet")
+		return
+	}
+	render.Write(w, r, http.StatusOK, Health{Status: "ready"})
+}
+
+// Status - Returns a handler that reports the catalog sizes, refreshes and cache files
+// along with the build version
+func Status(version string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		httpcache.NoStore(w)
+		now := time.Now()
+		snap := catalog.Current()
+		render.Write(w, r, http.StatusOK, ServerStatus{
+			Version:           version,
+			StartedAt:         status.StartedAt,
+			UptimeSeconds:     int64(now.Sub(status.StartedAt).Seconds()),
+			Ready:             ready(snap),
+			Loading:           Loading(),
+			Catalog:           catalogCounts(),
+			CatalogVersion:    snap.Version,
+			CatalogModifiedAt: snap.ModifiedAt,
+			Refreshes:         status.Refreshes(),
+			LastError:         status.LastError(),
+			CacheFiles:        cacheFiles(youtube.Main.DataDirectory, now),
+		})
+	}
+}
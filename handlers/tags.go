@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/render"
+)
+
+// TagsHeader - Response header with the tags of a picked video, playlist or channel,
+// for endpoints that return YouTube responses as they are
+const TagsHeader = "X-Tags"
+
+// TagsResponse - Every tag of the catalog
+type TagsResponse struct {
+	Tags []catalog.TagCount `json:"tags"`
+}
+
+// setTags - Sets the tags header, if there are tags
+func setTags(w http.ResponseWriter, tags []string) {
+	if len(tags) > 0 {
+		w.Header().Set(TagsHeader, strings.Join(tags, ", "))
+	}
+}
+
+// Tags - Lists every tag with the number of videos that have it, most used first
+func Tags(w http.ResponseWriter, r *http.Request) {
+	snap := snapshotOf(r)
+	if catalogNotModified(w, r, snap) {
+		return
+	}
+	render.Write(w, r, http.StatusOK, TagsResponse{Tags: snap.Tags()})
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	"github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/httpcache"
+	"github.com/lemonase/youtube-meme-api/render"
+	"github.com/lemonase/youtube-meme-api/status"
+	"github.com/lemonase/youtube-meme-api/tenant"
+)
+
+// ErrQuotaExceeded - A tenant's refreshes used its daily YouTube API quota
+var ErrQuotaExceeded = errors.New("the tenant has used its YouTube API quota for today")
+
+// TenantInfo - A registered tenant with the state of its catalog
+type TenantInfo struct {
+	tenant.Config
+	Path    string        `json:"path"`
+	Catalog CatalogCounts `json:"catalog"`
+	// LastRefresh - when the last refresh finished, missing before the first one
+	LastRefresh    *time.Time                `json:"lastRefresh,omitempty"`
+	QuotaUsedToday int64                     `json:"quotaUsedToday"`
+	Refreshes      map[string]status.Refresh `json:"refreshes"`
+	LastError      *status.Error             `json:"lastError,omitempty"`
+}
+
+// TenantList - Every registered tenant
+type TenantList struct {
+	Tenants []TenantInfo `json:"tenants"`
+}
+
+// catalogOf - Returns the catalog of the request's tenant, or the main catalog
+func catalogOf(r *http.Request) *catalog.Catalog {
+	if t := tenant.FromContext(r.Context()); t != nil {
+		return t.Catalog
+	}
+	return catalog.Main
+}
+
+// snapshotOf - Returns the latest snapshot of the request's catalog
+func snapshotOf(r *http.Request) *catalog.Snapshot {
+	return catalogOf(r).Current()
+}
+
+// sitePath - Returns the path the request's catalog is served under, "" for the main catalog
+func sitePath(r *http.Request) string {
+	if t := tenant.FromContext(r.Context()); t != nil {
+		return t.Path()
+	}
+	return ""
+}
+
+// siteTitle - Returns the title of the request's tenant, or SiteTitle
+func siteTitle(r *http.Request) string {
+	if t := tenant.FromContext(r.Context()); t != nil && t.Title != "" {
+		return t.Title
+	}
+	return SiteTitle
+}
+
+// sheetID - Returns the sheet the request's catalog comes from
+func sheetID(r *http.Request) string {
+	if t := tenant.FromContext(r.Context()); t != nil {
+		return t.SheetID
+	}
+	return sheets.Main.SheetID
+}
+
+// catalogLoading - Reports whether the first fetch of the request's catalog is still to come
+func catalogLoading(r *http.Request) bool {
+	if t := tenant.FromContext(r.Context()); t != nil {
+		return t.LastRefresh().IsZero()
+	}
+	return Loading()
+}
+
+// ServeTenant - Serves /t/{tenant}/... with next as if the request was for /..., with the tenant
+// in the request context so handlers use its catalog
+func ServeTenant(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, rest := pathID(r, tenant.PathPrefix)
+		t := tenant.Get(id)
+		if t == nil {
+			apierror.Write(w, r, http.StatusNotFound, "Tenant not found")
+			return
+		}
+		if rest == "" {
+			http.Redirect(w, r, t.Path()+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		r2 := r.WithContext(tenant.WithTenant(r.Context(), t))
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path, r2.URL.RawPath = rest, ""
+		next.ServeHTTP(w, r2)
+	}
+}
+
+// refreshTenant - Fetches a tenant's sheet and responses and counts the quota the fetch used,
+// the caller has to hold the lock of the tenant's catalog
+func refreshTenant(t *tenant.Tenant, forceRefresh bool) error {
+	if !t.QuotaLeft() {
+		t.Sources.Record("quota", false, ErrQuotaExceeded)
+		return ErrQuotaExceeded
+	}
+
+	used := t.Catalog.YouTube.QuotaUsed()
+	defer func() { t.Refreshed(t.Catalog.YouTube.QuotaUsed() - used) }()
+
+	slog.Info("refreshing tenant", "tenant", t.ID, "sheet", t.SheetID)
+	return fetchAllFromSheet(t.Catalog, forceRefresh)
+}
+
+// LoadTenants - Reads the registered tenants and builds their catalogs from their cached responses
+func LoadTenants() {
+	tenants, err := tenant.Load(youtube.Main.DataDirectory)
+	if err != nil {
+		slog.Error("could not load tenants", "err", err)
+		return
+	}
+	for _, t := range tenants {
+		c := t.Catalog
+		c.Lock()
+		if err := c.YouTube.ReadCached(); err != nil {
+			slog.Warn("could not read cached responses", "tenant", t.ID, "err", err)
+		}
+		if len(c.YouTube.VideoResponses) > 0 || len(c.YouTube.PlaylistItemResponses) > 0 {
+			c.Refresh()
+		}
+		c.Unlock()
+	}
+	if len(tenants) > 0 {
+		slog.Info("loaded tenants", "tenants", len(tenants))
+	}
+}
+
+// InitialTenantFetch - Fetches the sheet and responses of every tenant, from the cache files where they exist
+func InitialTenantFetch() {
+	for _, t := range tenant.All() {
+		t.Catalog.Lock()
+		if err := refreshTenant(t, false); err != nil {
+			slog.Error("could not fetch tenant", "tenant", t.ID, "err", err)
+		}
+		t.Catalog.Unlock()
+	}
+}
+
+// RefreshDueTenants - Refetches every tenant whose refresh interval has passed and that has quota left
+func RefreshDueTenants() {
+	for _, t := range tenant.All() {
+		if !t.Due(time.Now()) || !t.QuotaLeft() {
+			continue
+		}
+		t.Catalog.Lock()
+		if err := refreshTenant(t, true); err != nil {
+			slog.Error("scheduled tenant refresh failed", "tenant", t.ID, "err", err)
+		}
+		t.Catalog.Unlock()
+	}
+}
+
+// tenantInfo - Returns a tenant with the state of its catalog
+func tenantInfo(t *tenant.Tenant) TenantInfo {
+	info := TenantInfo{
+		Config:         t.Config,
+		Path:           t.Path() + "/",
+		Catalog:        countsOf(t.Catalog.Current()),
+		QuotaUsedToday: t.QuotaUsed(),
+		Refreshes:      t.Sources.Refreshes(),
+		LastError:      t.Sources.LastError(),
+	}
+	if last := t.LastRefresh(); !last.IsZero() {
+		info.LastRefresh = &last
+	}
+	return info
+}
+
+// Tenants - Lists the registered tenants (GET) or registers a tenant (POST) and fetches its catalog in the background
+func Tenants(w http.ResponseWriter, r *http.Request) {
+	httpcache.NoStore(w)
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		list := TenantList{Tenants: []TenantInfo{}}
+		for _, t := range tenant.All() {
+			list.Tenants = append(list.Tenants, tenantInfo(t))
+		}
+		render.Write(w, r, http.StatusOK, list)
+		return
+	}
+
+	var c tenant.Config
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "Could not decode request body: "+err.Error())
+		return
+	}
+	t, err := tenant.Add(c)
+	if errors.Is(err, tenant.ErrExists) {
+		apierror.Write(w, r, http.StatusConflict, "A tenant with the id "+c.ID+" already exists")
+		return
+	} else if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	slog.InfoContext(r.Context(), "registered tenant", "tenant", t.ID, "sheet", t.SheetID)
+
+	go func() {
+		t.Catalog.Lock()
+		defer t.Catalog.Unlock()
+		if err := refreshTenant(t, false); err != nil {
+			slog.Error("could not fetch tenant", "tenant", t.ID, "err", err)
+		}
+	}()
+	w.Header().Set("Location", "/api/admin/tenants/"+t.ID)
+	render.Write(w, r, http.StatusCreated, tenantInfo(t))
+}
+
+// TenantByID - Gets (GET) or unregisters (DELETE) a tenant (/api/admin/tenants/{id}),
+// or refetches its catalog (POST /api/admin/tenants/{id}/refresh)
+func TenantByID(w http.ResponseWriter, r *http.Request) {
+	httpcache.NoStore(w)
+	id, rest := pathID(r, "/api/admin/tenants/")
+	t := tenant.Get(id)
+	if t == nil || (rest != "" && rest != "/refresh") {
+		apierror.Write(w, r, http.StatusNotFound, "Tenant not found")
+		return
+	}
+
+	switch {
+	case rest == "/refresh" && r.Method == http.MethodPost:
+		t.Catalog.Lock()
+		err := refreshTenant(t, true)
+		t.Catalog.Unlock()
+		if errors.Is(err, ErrQuotaExceeded) {
+			apierror.Write(w, r, http.StatusTooManyRequests, "Tenant "+t.ID+" has used its YouTube API quota for today")
+			return
+		}
+		snap := t.Catalog.Current()
+		if err != nil && (len(snap.Videos)+len(snap.PlaylistItems) == 0 || youtube.Canceled()) {
+			refreshFailed(w, r, "tenant "+t.ID, err)
+			return
+		}
+		render.Write(w, r, http.StatusOK, RefreshResponse{Refreshed: "tenant", Changed: true, Catalog: countsOf(snap), Errors: errorMessages(err)})
+	case rest == "" && r.Method == http.MethodDelete:
+		if _, err := tenant.Remove(t.ID); err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		rendered.drop(t.Path())
+		slog.InfoContext(r.Context(), "removed tenant", "tenant", t.ID)
+		w.WriteHeader(http.StatusNoContent)
+	case rest == "" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+		render.Write(w, r, http.StatusOK, tenantInfo(t))
+	default:
+		allow := "GET, DELETE"
+		if rest == "/refresh" {
+			allow = http.MethodPost
+		}
+		w.Header().Set("Allow", allow)
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "Method not allowed, use "+strings.Replace(allow, ", ", " or ", 1))
+	}
+}
@@ -0,0 +1,72 @@
+package httpcache
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NotModified - Sets the ETag and Last-Modified headers and reports whether the client's
+// copy is still fresh (If-None-Match takes precedence over If-Modified-Since), in which
+// case a 304 has been written and the caller should not write a body
+func NotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if etag != "" && etagMatches(match, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+		return false
+	}
+
+	if since := r.Header.Get("If-Modified-Since"); since != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(since)
+		// HTTP dates only have second precision
+		if err == nil && !lastModified.Truncate(time.Second).After(t) {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches - Reports whether an If-None-Match header matches the etag, using weak comparison
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Vary - Adds fields to the Vary header that are not in it yet
+func Vary(h http.Header, fields ...string) {
+	present := make(map[string]bool)
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			present[http.CanonicalHeaderKey(strings.TrimSpace(f))] = true
+		}
+	}
+	for _, f := range fields {
+		if !present[http.CanonicalHeaderKey(f)] {
+			h.Add("Vary", f)
+			present[http.CanonicalHeaderKey(f)] = true
+		}
+	}
+}
+
+// NoStore - Tells clients and proxies not to cache the response
+func NoStore(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+}
@@ -0,0 +1,318 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	ytwrapper "github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Kinds of issues
+const (
+	// KindUnparseable - the server can not read a usable ID from the cell
+	KindUnparseable = "unparseable"
+	// KindNotYouTube - the cell links somewhere else
+	KindNotYouTube = "not-youtube"
+	// KindWrongColumn - the cell links to a video, playlist or channel in another type's column
+	KindWrongColumn = "wrong-column"
+	// KindDuplicate - the same video, playlist or channel is on the sheet more than once
+	KindDuplicate = "duplicate"
+	// KindUnresolved - YouTube returned nothing for the ID
+	KindUnresolved = "unresolved"
+)
+
+// Issue - A problem with a cell of the sheet
+type Issue struct {
+	// Column - "videos", "playlists" or "channels"
+	Column string `json:"column"`
+	// Cell - the A1 reference of the cell, like "A12"
+	Cell    string `json:"cell"`
+	Row     int    `json:"row"`
+	Value   string `json:"value"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// Report - The issues of every video, playlist and channel cell of the sheet
+type Report struct {
+	SheetID string `json:"sheetId"`
+	// Checked - the number of filled in cells that were checked
+	Checked int `json:"checked"`
+	// Resolved - whether IDs were checked against the YouTube responses, which are not loaded before the first fetch
+	Resolved  bool      `json:"resolved"`
+	Issues    []Issue   `json:"issues"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// OK - Reports whether the sheet has no issues
+func (r *Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// ID formats, the server's parsers take everything after the parameter so trailing
+// parameters end up in the ID
+var (
+	videoID    = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	playlistID = regexp.MustCompile(`^[A-Za-z0-9_-]{10,}$`)
+	channelID  = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// youTubeHosts - The hosts of YouTube links
+var youTubeHosts = map[string]bool{
+	"youtube.com":       true,
+	"www.youtube.com":   true,
+	"m.youtube.com":     true,
+	"music.youtube.com": true,
+	"youtu.be":          true,
+}
+
+// column - A sheet column of links to one type
+type column struct {
+	name   string
+	kind   string
+	rng    string
+	values [][]interface{}
+	parse  func(string) (string, error)
+	valid  *regexp.Regexp
+}
+
+func columns(sheet *sheets.Sheet) []column {
+	return []column{
+		{"videos", "video", sheet.VideoRange, sheet.VideoValues, ytwrapper.VideoIDFromURL, videoID},
+		{"playlists", "playlist", sheet.PlaylistRange, sheet.PlaylistValues, ytwrapper.PlaylistIDFromURL, playlistID},
+		{"channels", "channel", sheet.ChannelRange, sheet.ChannelValues, ytwrapper.ChannelIDFromURL, channelID},
+	}
+}
+
+// linkKinds - Returns what a link points at, "video", "playlist" and/or "channel",
+// or an issue kind and message if it is not a YouTube link the server understands
+func linkKinds(value string) ([]string, string, string) {
+	raw := value
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, KindUnparseable, "not a link"
+	}
+	if !youTubeHosts[strings.ToLower(u.Host)] {
+		return nil, KindNotYouTube, fmt.Sprintf("links to %s instead of YouTube", u.Host)
+	}
+
+	var kinds []string
+	q := u.Query()
+	switch {
+	case strings.EqualFold(u.Host, "youtu.be") && len(u.Path) > 1,
+		u.Path == "/watch" && q.Get("v") != "",
+		strings.HasPrefix(u.Path, "/shorts/"), strings.HasPrefix(u.Path, "/embed/"), strings.HasPrefix(u.Path, "/live/"):
+		kinds = append(kinds, "video")
+	case strings.HasPrefix(u.Path, "/channel/"), strings.HasPrefix(u.Path, "/c/"),
+		strings.HasPrefix(u.Path, "/user/"), strings.HasPrefix(u.Path, "/@"):
+		kinds = append(kinds, "channel")
+	}
+	if q.Get("list") != "" {
+		kinds = append(kinds, "playlist")
+	}
+	if len(kinds) == 0 {
+		return nil, KindUnparseable, "not a link to a video, playlist or channel"
+	}
+	return kinds, "", ""
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Check - Checks the video, playlist and channel columns of the loaded sheet values, and the IDs
+// against the responses loaded in store. Callers have to keep the sheet and store from changing
+// while it runs
+func Check(sheet *sheets.Sheet, store *ytwrapper.Store) Report {
+	report := Report{SheetID: sheet.SheetID, Issues: []Issue{}, CheckedAt: time.Now()}
+	report.Resolved = len(store.VideoResponses)+len(store.PlaylistResponses)+len(store.ChannelResponses) > 0
+
+	cols := columns(sheet)
+	letters := make(map[string]string)
+	for _, c := range cols {
+		if r, err := sheets.ParseRange(c.rng); err == nil {
+			letters[c.kind] = sheets.ColumnName(r.Column)
+		}
+	}
+
+	seen := make(map[string]string)
+	// cells of the playlists on the sheet, including the uploads playlists of channels
+	playlistCells := make(map[string]string)
+	// cells of the videos column by video ID
+	videoCells := make(map[string]Issue)
+
+	// channels first, so playlists and videos can refer to their uploads
+	for i := len(cols) - 1; i >= 0; i-- {
+		c := cols[i]
+		r, err := sheets.ParseRange(c.rng)
+		if err != nil {
+			continue
+		}
+		for row := range c.values {
+			value := sheets.CellString(c.values, row)
+			if value == "" {
+				continue
+			}
+			report.Checked++
+			cell := Issue{Column: c.name, Cell: r.Cell(row), Row: r.FirstRow + row, Value: value}
+			add := func(kind string, format string, args ...interface{}) {
+				cell.Kind, cell.Message = kind, fmt.Sprintf(format, args...)
+				report.Issues = append(report.Issues, cell)
+			}
+
+			kinds, kind, msg := linkKinds(value)
+			if kind != "" {
+				add(kind, "%s", msg)
+				continue
+			}
+			if !contains(kinds, c.kind) {
+				add(KindWrongColumn, "links to a %s, it belongs in the %s column (%s)", kinds[0], kinds[0]+"s", letters[kinds[0]])
+				continue
+			}
+			id, err := c.parse(value)
+			if err != nil {
+				add(KindUnparseable, "the server can not read a %s ID from it", c.kind)
+				continue
+			}
+			if !c.valid.MatchString(id) {
+				add(KindUnparseable, "the server reads the %s ID %q from it, which is not a valid ID", c.kind, id)
+				continue
+			}
+
+			key := c.kind + ":" + id
+			if first, ok := seen[key]; ok {
+				add(KindDuplicate, "same %s as %s", c.kind, first)
+				continue
+			}
+			seen[key] = cell.Cell
+
+			switch c.kind {
+			case "channel":
+				ch := findChannel(store, id)
+				if ch == nil {
+					if report.Resolved {
+						add(KindUnresolved, "YouTube returned no channel for %q", id)
+					}
+					continue
+				}
+				if ch.ContentDetails != nil && ch.ContentDetails.RelatedPlaylists != nil && ch.ContentDetails.RelatedPlaylists.Uploads != "" {
+					playlistCells[ch.ContentDetails.RelatedPlaylists.Uploads] = cell.Cell
+				}
+			case "playlist":
+				if channelCell, ok := playlistCells[id]; ok {
+					add(KindDuplicate, "the playlist has the uploads of the channel at %s", channelCell)
+					continue
+				}
+				playlistCells[id] = cell.Cell
+				if report.Resolved && !hasPlaylist(store, id) {
+					add(KindUnresolved, "YouTube returned no playlist for %q, it is private, deleted or was added after the last refresh", id)
+				}
+			case "video":
+				videoCells[id] = cell
+				if report.Resolved && !hasVideo(store, id) {
+					add(KindUnresolved, "YouTube returned no video for %q, it is private, deleted or was added after the last refresh", id)
+				}
+			}
+		}
+	}
+
+	// videos that are also in a playlist or channel on the sheet
+	inPlaylist := make(map[string]string)
+	for _, page := range store.PlaylistItemResponses {
+		for _, item := range page.Items {
+			if item.Snippet == nil || item.ContentDetails == nil {
+				continue
+			}
+			if playlistCell, ok := playlistCells[item.Snippet.PlaylistId]; ok {
+				if _, dup := inPlaylist[item.ContentDetails.VideoId]; !dup {
+					inPlaylist[item.ContentDetails.VideoId] = playlistCell
+				}
+			}
+		}
+	}
+	for id, cell := range videoCells {
+		if playlistCell, ok := inPlaylist[id]; ok {
+			cell.Kind, cell.Message = KindDuplicate, "the video is also in the playlist or channel at "+playlistCell
+			report.Issues = append(report.Issues, cell)
+		}
+	}
+
+	order := map[string]int{"videos": 0, "playlists": 1, "channels": 2}
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.Column != b.Column {
+			return order[a.Column] < order[b.Column]
+		}
+		return a.Row < b.Row
+	})
+	return report
+}
+
+// findChannel - Returns the loaded channel with an ID or custom URL (for /c/ and /user/ links), or nil
+func findChannel(store *ytwrapper.Store, id string) *youtube.Channel {
+	for _, res := range store.ChannelResponses {
+		for _, ch := range res.Items {
+			if ch.Id == id {
+				return ch
+			}
+			if ch.Snippet != nil && ch.Snippet.CustomUrl != "" && strings.EqualFold(strings.TrimPrefix(ch.Snippet.CustomUrl, "@"), id) {
+				return ch
+			}
+		}
+	}
+	return nil
+}
+
+func hasPlaylist(store *ytwrapper.Store, id string) bool {
+	for _, res := range store.PlaylistResponses {
+		for _, pl := range res.Items {
+			if pl.Id == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasVideo(store *ytwrapper.Store, id string) bool {
+	for _, res := range store.VideoResponses {
+		for _, v := range res.Items {
+			if v.Id == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteText - Writes the report as an aligned table for terminals
+func WriteText(w io.Writer, r Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, issue := range r.Issues {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", issue.Cell, issue.Kind, issue.Value, issue.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if !r.Resolved {
+		fmt.Fprintln(w, "no YouTube responses are loaded, IDs were not checked against YouTube")
+	}
+	_, err := fmt.Fprintf(w, "checked %d cells, %d issues\n", r.Checked, len(r.Issues))
+	return err
+}
@@ -0,0 +1,145 @@
+package lint
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	ytwrapper "github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"google.golang.org/api/youtube/v3"
+)
+
+// values - Returns sheet values with one link per row, "" for an empty row
+func values(links ...string) [][]interface{} {
+	rows := make([][]interface{}, len(links))
+	for i, l := range links {
+		if l != "" {
+			rows[i] = []interface{}{l}
+		}
+	}
+	return rows
+}
+
+// testStore - Responses for the video abcdefghijk, the playlist PLabcdefghij and the channel
+// UCabc with the uploads playlist UUabcdefghij, which has the video bcdefghijkl
+func testStore() *ytwrapper.Store {
+	return &ytwrapper.Store{
+		VideoResponses:    []*youtube.VideoListResponse{{Items: []*youtube.Video{{Id: "abcdefghijk"}}}},
+		PlaylistResponses: []*youtube.PlaylistListResponse{{Items: []*youtube.Playlist{{Id: "PLabcdefghij"}}}},
+		ChannelResponses: []*youtube.ChannelListResponse{{Items: []*youtube.Channel{{
+			Id:             "UCabc",
+			ContentDetails: &youtube.ChannelContentDetails{RelatedPlaylists: &youtube.ChannelContentDetailsRelatedPlaylists{Uploads: "UUabcdefghij"}},
+		}}}},
+		PlaylistItemResponses: []*youtube.PlaylistItemListResponse{{Items: []*youtube.PlaylistItem{{
+			Snippet:        &youtube.PlaylistItemSnippet{PlaylistId: "UUabcdefghij"},
+			ContentDetails: &youtube.PlaylistItemContentDetails{VideoId: "bcdefghijkl"},
+		}}}},
+	}
+}
+
+// issue - The cell and kind of an issue, what the table checks
+type issue struct{ cell, kind string }
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name                        string
+		videos, playlists, channels [][]interface{}
+		store                       *ytwrapper.Store
+		want                        []issue
+	}{
+		{
+			name:      "clean",
+			videos:    values("https://youtu.be/abcdefghijk", ""),
+			playlists: values("https://www.youtube.com/playlist?list=PLabcdefghij"),
+			channels:  values("https://www.youtube.com/channel/UCabc"),
+			store:     testStore(),
+		},
+		{
+			name:   "unparseable",
+			videos: values("not a link at all", "https://youtu.be/short", "https://www.youtube.com/about"),
+			want:   []issue{{"A2", KindUnparseable}, {"A3", KindUnparseable}, {"A4", KindUnparseable}},
+		},
+		{
+			name:      "not youtube",
+			videos:    values("https://vimeo.com/123456"),
+			playlists: values("example.com/playlist?list=PLabcdefghij"),
+			want:      []issue{{"A2", KindNotYouTube}, {"C2", KindNotYouTube}},
+		},
+		{
+			name:      "wrong column",
+			videos:    values("https://www.youtube.com/channel/UCabc"),
+			playlists: values("https://youtu.be/abcdefghijk"),
+			channels:  values("https://www.youtube.com/playlist?list=PLabcdefghij"),
+			want:      []issue{{"A2", KindWrongColumn}, {"C2", KindWrongColumn}, {"E2", KindWrongColumn}},
+		},
+		{
+			name: "duplicate",
+			// a start time does not make it another video
+			videos:    values("https://youtu.be/abcdefghijk", "https://www.youtube.com/watch?v=abcdefghijk&t=30", "https://youtu.be/bcdefghijkl"),
+			playlists: values("https://www.youtube.com/playlist?list=UUabcdefghij"),
+			channels:  values("https://www.youtube.com/channel/UCabc", "https://www.youtube.com/channel/UCabc"),
+			store:     testStore(),
+			// the video in A4 is in the uploads of the channel (only as a playlist item, so it is
+			// unresolved as well), which is also in the playlists column
+			want: []issue{{"A3", KindDuplicate}, {"A4", KindUnresolved}, {"A4", KindDuplicate}, {"C2", KindDuplicate}, {"E3", KindDuplicate}},
+		},
+		{
+			name:      "unresolved",
+			videos:    values("https://youtu.be/zzzzzzzzzzz"),
+			playlists: values("https://www.youtube.com/playlist?list=PLzzzzzzzzzz"),
+			channels:  values("https://www.youtube.com/channel/UCzzz"),
+			store:     testStore(),
+			want:      []issue{{"A2", KindUnresolved}, {"C2", KindUnresolved}, {"E2", KindUnresolved}},
+		},
+		{
+			// nothing is loaded before the first fetch, so missing IDs are not reported
+			name:   "not resolved",
+			videos: values("https://youtu.be/zzzzzzzzzzz"),
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet := &sheets.Sheet{
+				SheetID:    "sheet",
+				VideoRange: "Sheet1!A2:A1000", PlaylistRange: "Sheet1!C2:C1000", ChannelRange: "Sheet1!E2:E1000",
+				VideoValues: tt.videos, PlaylistValues: tt.playlists, ChannelValues: tt.channels,
+			}
+			store := tt.store
+			if store == nil {
+				store = &ytwrapper.Store{}
+			}
+
+			report := Check(sheet, store)
+			var got []issue
+			for _, i := range report.Issues {
+				got = append(got, issue{i.Cell, i.Kind})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got issues %v, want %v", got, tt.want)
+			}
+			if report.OK() != (len(tt.want) == 0) {
+				t.Errorf("OK() = %v with %d issues", report.OK(), len(tt.want))
+			}
+			if report.Resolved != (tt.store != nil) {
+				t.Errorf("Resolved = %v", report.Resolved)
+			}
+		})
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	sheet := &sheets.Sheet{VideoRange: "Sheet1!A2:A1000", VideoValues: values("https://vimeo.com/123456", "https://youtu.be/abcdefghijk")}
+	var buf bytes.Buffer
+	if err := WriteText(&buf, Check(sheet, &ytwrapper.Store{})); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"A2  not-youtube  https://vimeo.com/123456", "IDs were not checked against YouTube", "checked 2 cells, 1 issues"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+}
@@ -3,54 +3,218 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
-	"strings"
 
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	"github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/auth"
+	"github.com/lemonase/youtube-meme-api/catalog"
 	"github.com/lemonase/youtube-meme-api/client"
+	"github.com/lemonase/youtube-meme-api/config"
+	"github.com/lemonase/youtube-meme-api/export"
+	"github.com/lemonase/youtube-meme-api/handlers"
+	"github.com/lemonase/youtube-meme-api/lint"
+	"github.com/lemonase/youtube-meme-api/logging"
+	"github.com/lemonase/youtube-meme-api/random"
+	"github.com/lemonase/youtube-meme-api/ratelimit"
 	"github.com/lemonase/youtube-meme-api/server"
 )
 
 var (
-	port       = flag.String("port", "8000", "Port to listen on (default is 8000)")
-	apiKey     = flag.String("key", "", "API key to access Google resources")
-	secretFile = flag.String("secretFile", "", "Credentials file downloaded from GCP (/path/to/credentials.json)")
+	settings    = config.RegisterFlags(flag.CommandLine)
+	configFile  = flag.String("config", "", "YAML config file, settings in the environment and on the command line override it (env CONFIG_FILE, default "+config.DefaultFile+" if it exists)")
+	printConfig = flag.Bool("print-config", false, "Print the effective config with secrets redacted and exit, non-zero if it is invalid")
+
+	exportFile   = flag.String("export", "", "Write the catalog to a playlist file (.m3u or .xspf) and exit instead of starting the server")
+	exportFilter = flag.String("exportFilter", "", "Filters and seed for --export in query string form (e.g. \"channel=UC...&seed=42\")")
 )
 
-func handleArgs() {
+// validateMode - The argument that lints the sheet and exits instead of starting the server
+const validateMode = "validate"
+
+func handleArgs() *config.Config {
 	// flag parsing
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s: [flags] [%s]\n", os.Args[0], validateMode)
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s\n    \tCheck the sheet for broken, duplicate and misplaced links and exit, non-zero if it has any\n", validateMode)
 		flag.PrintDefaults()
 		fmt.Println()
 	}
 	flag.Parse()
+	if flag.NArg() > 1 || flag.NArg() == 1 && flag.Arg(0) != validateMode {
+		flag.Usage()
+		fmt.Fprintf(os.Stderr, "Unknown arguments %v\n", flag.Args())
+		os.Exit(1)
+	}
+
+	// config file, then environment, then flags
+	file := *configFile
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	if file == "" {
+		if _, err := os.Stat(config.DefaultFile); err == nil {
+			file = config.DefaultFile
+		}
+	}
+	c, err := config.Load(file, os.Getenv, settings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	invalid := c.Validate()
+
+	if *printConfig {
+		out, err := c.Redacted().YAML()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if file != "" {
+			fmt.Printf("# from %s, the environment and flags\n", file)
+		}
+		os.Stdout.Write(out)
+		if invalid != nil {
+			fmt.Fprintln(os.Stderr, invalid)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if invalid != nil {
+		flag.Usage()
+		fmt.Fprintln(os.Stderr, invalid)
+		os.Exit(1)
+	}
+
+	// export parameters
+	if *exportFile != "" {
+		if _, ok := export.FormatOf(*exportFile); !ok {
+			fmt.Fprintf(os.Stderr, "Unknown playlist format for %s, use .m3u or .xspf\n", *exportFile)
+			os.Exit(1)
+		}
+	}
+
+	applyConfig(c)
+	return c
+}
+
+// applyConfig - Sets up every package from a validated config
+func applyConfig(c *config.Config) {
+	// logging parameters, set first so everything after is logged in the format
+	if err := logging.Setup(os.Stderr, c.Log.Format, c.Log.Level); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	// client/api parameters
-	if *apiKey != "" {
-		client.InitClientsWithAPIKey(*apiKey)
-	} else if os.Getenv("YT_API_KEY") != "" {
-		client.InitClientsWithAPIKey(os.Getenv("YT_API_KEY"))
-	} else if *secretFile != "" {
-		client.InitClientsWithSecretJSONFile(*secretFile)
+	if c.Google.APIKey != "" {
+		client.InitClientsWithAPIKey(c.Google.APIKey)
 	} else {
-		flag.Usage()
-		fmt.Fprintf(os.Stderr, "Please specify either an API Key or a credentials.json file\n")
+		client.InitClientsWithSecretJSONFile(c.Google.SecretFile)
+	}
+
+	// sheet and youtube parameters
+	sheet := sheets.Main
+	sheet.SheetID = c.Sheet.ID
+	sheet.VideoRange, sheet.VideoWeightRange = c.Sheet.Ranges.Videos, c.Sheet.Ranges.VideoWeights
+	sheet.PlaylistRange, sheet.PlaylistWeightRange = c.Sheet.Ranges.Playlists, c.Sheet.Ranges.PlaylistWeights
+	sheet.ChannelRange, sheet.ChannelWeightRange = c.Sheet.Ranges.Channels, c.Sheet.Ranges.ChannelWeights
+	sheet.SearchRange, sheet.TagRange = c.Sheet.Ranges.Searches, c.Sheet.Ranges.Tags
+	sheet.StartRange, sheet.EndRange = c.Sheet.Ranges.Starts, c.Sheet.Ranges.Ends
+	sheet.TitleRange, sheet.NoteRange = c.Sheet.Ranges.Titles, c.Sheet.Ranges.Notes
+	sheet.DateRange, sheet.CreditRange = c.Sheet.Ranges.Dates, c.Sheet.Ranges.Credits
+	youtube.PageSize = c.YouTube.PageSize
+	youtube.Main.DataDirectory = c.YouTube.DataDir
+
+	// site parameters
+	handlers.SiteTitle = c.Site.Title
+	handlers.HomeTitle = c.Site.HomeTitle
+
+	// admin and webhook parameters, validated already
+	tokens, _ := auth.ParseTokens(c.Auth.AdminTokens)
+	auth.SetTokens(tokens)
+	handlers.SheetsHookSecret = c.Auth.HookSecret
+
+	// rate limit parameters
+	if err := ratelimit.SetBudgets(c.RateLimit.Budgets); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	proxies, _ := ratelimit.ParseCIDRs(c.RateLimit.TrustedProxies)
+	ratelimit.SetTrustedProxies(proxies)
+	keys, _ := ratelimit.ParseAPIKeys(c.Auth.ClientKeys)
+	ratelimit.SetAPIKeys(keys)
+
+	// random parameters
+	if err := random.SetDefaultStrategy(c.Strategy); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
 	// server parameters
-	if os.Getenv("PORT") != "" {
-		*port = os.Getenv("PORT")
+	server.DrainTimeout = c.Server.DrainTimeout
+	server.RefreshInterval = c.Refresh.Interval
+}
+
+// exportPlaylist - Writes the catalog to the --export file
+func exportPlaylist() {
+	q, err := url.ParseQuery(*exportFilter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not parse --exportFilter: %v\n", err)
+		os.Exit(1)
+	}
+	opts, err := export.OptionsFromQuery(q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --exportFilter: %v\n", err)
+		os.Exit(1)
 	}
 
-	// prepend ":" for port if not already
-	if strings.Index(*port, ":") == -1 {
-		*port = ":" + *port
+	tracks := export.Tracks(catalog.Current(), opts)
+	if err := export.WriteFile(*exportFile, handlers.SiteTitle, tracks); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write %s: %v\n", *exportFile, err)
+		os.Exit(1)
+	}
+	if opts.Shuffle {
+		fmt.Printf("Wrote %d videos to %s (seed %d)\n", len(tracks), *exportFile, opts.Seed)
+	} else {
+		fmt.Printf("Wrote %d videos to %s\n", len(tracks), *exportFile)
 	}
 }
 
+// validateSheet - Fetches the sheet and the YouTube data it refers to (from the cache files
+// where they exist), prints the lint report and returns the exit code: 0 if the sheet has no
+// issues, 1 if it has and 2 if it could not be fetched or the report not written
+func validateSheet(stdout io.Writer, stderr io.Writer) int {
+	err := handlers.InitialFetch()
+	if err != nil && sheets.Main.VideoLength+sheets.Main.PlaylistLength+sheets.Main.ChannelLength == 0 {
+		fmt.Fprintf(stderr, "Could not fetch the sheet: %v\n", err)
+		return 2
+	}
+	report := handlers.LintSheet(nil)
+	if err := lint.WriteText(stdout, report); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
 func main() {
-	handleArgs()
-	server.FetchInitResources()
-	server.InitServer(*port)
+	c := handleArgs()
+	if flag.Arg(0) == validateMode {
+		os.Exit(validateSheet(os.Stdout, os.Stderr))
+	}
+	if *exportFile != "" {
+		server.FetchMainResources()
+		exportPlaylist()
+		return
+	}
+	// serve the responses of the last run (or 503) right away and fetch in the background
+	server.LoadCachedResources()
+	go server.FetchInitResources()
+	server.InitServer(c.Server.Listen)
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	"github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/client"
+	"google.golang.org/api/option"
+	sheetsapi "google.golang.org/api/sheets/v4"
+	youtubeapi "google.golang.org/api/youtube/v3"
+)
+
+// fakeAPIs - Points the clients at a server that answers the videos column with videos (and
+// every other column with nothing) and knows every video, or fails every sheet range if
+// videos is nil
+func fakeAPIs(t *testing.T, videos []string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "/spreadsheets/") && videos == nil:
+			http.Error(w, `{"error": {"code": 500, "message": "backend error"}}`, http.StatusInternalServerError)
+		case strings.Contains(r.URL.Path, "/spreadsheets/"):
+			res := sheetsapi.ValueRange{}
+			if strings.HasSuffix(r.URL.Path, "!A2:A1000") {
+				for _, v := range videos {
+					res.Values = append(res.Values, []interface{}{v})
+				}
+			}
+			json.NewEncoder(w).Encode(res)
+		case strings.HasSuffix(r.URL.Path, "/videos"):
+			json.NewEncoder(w).Encode(youtubeapi.VideoListResponse{Items: []*youtubeapi.Video{{Id: r.URL.Query().Get("id")}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	opts := []option.ClientOption{option.WithEndpoint(srv.URL), option.WithHTTPClient(srv.Client())}
+	sheetsSvc, err := sheetsapi.NewService(context.Background(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	youtubeSvc, err := youtubeapi.NewService(context.Background(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := client.Services
+	client.Services.Sheets, client.Services.YouTube = *sheetsSvc, *youtubeSvc
+	t.Cleanup(func() { client.Services = prev })
+
+	// every case starts like a new process, without the values and responses of the last one
+	sheet, store := *sheets.Main, *youtube.Main
+	youtube.Main.DataDirectory = t.TempDir()
+	t.Cleanup(func() { *sheets.Main, *youtube.Main = sheet, store })
+}
+
+func TestValidateSheet(t *testing.T) {
+	tests := []struct {
+		name   string
+		videos []string
+		want   int
+		output string
+	}{
+		{"clean", []string{"https://youtu.be/abcdefghijk"}, 0, "checked 1 cells, 0 issues"},
+		{"issues", []string{"https://youtu.be/abcdefghijk", "https://vimeo.com/123456"}, 1, "A3  not-youtube"},
+		{"sheet fails", nil, 2, "Could not fetch the sheet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAPIs(t, tt.videos)
+			var stdout, stderr bytes.Buffer
+			if got := validateSheet(&stdout, &stderr); got != tt.want {
+				t.Errorf("validateSheet() = %d, want %d (stdout %q, stderr %q)", got, tt.want, stdout.String(), stderr.String())
+			}
+			if out := stdout.String() + stderr.String(); !strings.Contains(out, tt.output) {
+				t.Errorf("output %q does not contain %q", out, tt.output)
+			}
+		})
+	}
+}
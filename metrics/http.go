@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var httpRequests = NewCounterVec("http_requests_total", "HTTP requests by route, method and status code", "route", "method", "status")
+
+var httpDuration = NewHistogramVec("http_request_duration_seconds", "Time to serve HTTP requests by route and method", nil, "route", "method")
+
+var httpResponseBytes = NewCounterVec("http_response_bytes_total", "Bytes written in HTTP response bodies (before compression) by route", "route")
+
+// statusWriter - Records the status code and body size of a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+// Flush - Passes flushes of streamed responses on
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// methodLabel - Returns the method, or "other" for non standard methods so clients
+// can not create label values
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "other"
+}
+
+// Middleware - Counts and times the requests of a route, route should be the mux pattern
+// (not the request path) to keep the number of label values small
+func Middleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		completed := false
+		defer func() {
+			status := sw.status
+			if !completed {
+				// the handler panicked, which is answered with a 500 further up
+				status = http.StatusInternalServerError
+			} else if status == 0 {
+				status = http.StatusOK
+			}
+			method := methodLabel(r.Method)
+			httpRequests.Inc(route, method, strconv.Itoa(status))
+			httpDuration.Observe(time.Since(start).Seconds(), route, method)
+			httpResponseBytes.Add(float64(sw.bytes), route)
+		}()
+		next(sw, r)
+		completed = true
+	}
+}
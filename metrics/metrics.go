@@ -0,0 +1,273 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Namespace - Prefix of every metric name
+const Namespace = "ytmeme_"
+
+// ContentType - The Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets - Default histogram buckets in seconds
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample - A value and the values of its labels
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// metric - Anything that can be written in the text format
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+var registryMu sync.Mutex
+var registry []metric
+
+// register - Adds a metric to the ones written by Handler, in registration order
+func register(m metric) {
+	registryMu.Lock()
+	registry = append(registry, m)
+	registryMu.Unlock()
+}
+
+// desc - The name, help and label names of a metric
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+// header - Writes the HELP and TYPE lines
+func (d desc) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, kind)
+}
+
+// sample - Writes a sample line, extra is an additional label pair like `le="0.5"`
+func (d desc) sample(w *bufio.Writer, suffix string, labelValues []string, extra string, value float64) {
+	w.WriteString(d.name + suffix)
+	if len(d.labels) > 0 || extra != "" {
+		w.WriteByte('{')
+		for i, l := range d.labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l + `="` + escapeLabel(labelValues[i]) + `"`)
+		}
+		if extra != "" {
+			if len(d.labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extra)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+// key - Joins label values into a map key
+func key(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// checkLabels - Panics if the number of label values does not match the label names,
+// which is a programming error like in the Prometheus client
+func (d desc) checkLabels(labelValues []string) {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metric %s takes %d label values, got %d", d.name, len(d.labels), len(labelValues)))
+	}
+}
+
+// CounterVec - A counter for each combination of label values
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*Sample
+}
+
+// NewCounterVec - Creates and registers a counter, the name is prefixed with Namespace
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{Namespace + name, help, labels}, values: make(map[string]*Sample)}
+	register(c)
+	return c
+}
+
+// Add - Adds a non-negative value to the counter of the label values
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	c.checkLabels(labelValues)
+	k := key(labelValues)
+	c.mu.Lock()
+	s, ok := c.values[k]
+	if !ok {
+		s = &Sample{LabelValues: append([]string{}, labelValues...)}
+		c.values[k] = s
+	}
+	s.Value += v
+	c.mu.Unlock()
+}
+
+// Inc - Adds one to the counter of the label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.header(w, "counter")
+	c.mu.Lock()
+	samples := sortedSamples(c.values)
+	c.mu.Unlock()
+	for _, s := range samples {
+		c.sample(w, "", s.LabelValues, "", s.Value)
+	}
+}
+
+// HistogramVec - A histogram for each combination of label values
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogram
+}
+
+type histogram struct {
+	labelValues []string
+	// counts - observations per bucket (not cumulative), the last one is +Inf
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec - Creates and registers a histogram with the upper bounds of its buckets
+// in increasing order (DefBuckets if nil), the name is prefixed with Namespace
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	h := &HistogramVec{desc: desc{Namespace + name, help, labels}, buckets: buckets, values: make(map[string]*histogram)}
+	register(h)
+	return h
+}
+
+// Observe - Adds an observation to the histogram of the label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.checkLabels(labelValues)
+	k := key(labelValues)
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.mu.Lock()
+	hist, ok := h.values[k]
+	if !ok {
+		hist = &histogram{labelValues: append([]string{}, labelValues...), counts: make([]uint64, len(h.buckets)+1)}
+		h.values[k] = hist
+	}
+	hist.counts[i]++
+	hist.sum += v
+	hist.count++
+	h.mu.Unlock()
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.header(w, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		hist := h.values[k]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += hist.counts[i]
+			h.sample(w, "_bucket", hist.labelValues, `le="`+formatFloat(upper)+`"`, float64(cumulative))
+		}
+		h.sample(w, "_bucket", hist.labelValues, `le="+Inf"`, float64(hist.count))
+		h.sample(w, "_sum", hist.labelValues, "", hist.sum)
+		h.sample(w, "_count", hist.labelValues, "", float64(hist.count))
+	}
+}
+
+// GaugeFunc - A gauge whose samples are collected when the metrics are scraped
+type GaugeFunc struct {
+	desc
+	collect func() []Sample
+}
+
+// NewGaugeFunc - Creates and registers a gauge that calls collect on every scrape,
+// the name is prefixed with Namespace
+func NewGaugeFunc(name string, help string, collect func() []Sample, labels ...string) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{Namespace + name, help, labels}, collect: collect}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.header(w, "gauge")
+	for _, s := range g.collect() {
+		g.checkLabels(s.LabelValues)
+		g.sample(w, "", s.LabelValues, "", s.Value)
+	}
+}
+
+// sortedSamples - Returns copies of the samples ordered by their label values
+func sortedSamples(values map[string]*Sample) []Sample {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	samples := make([]Sample, len(keys))
+	for i, k := range keys {
+		samples[i] = *values[k]
+	}
+	return samples
+}
+
+// formatFloat - Formats a value like Prometheus does
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// Handler - Serves every registered metric in the Prometheus text format
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Cache-Control", "no-store")
+
+	registryMu.Lock()
+	metrics := append([]metric{}, registry...)
+	registryMu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	bw.Flush()
+}
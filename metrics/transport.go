@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var apiCalls = NewCounterVec("google_api_calls_total", "Calls to the YouTube and Sheets APIs by API and method", "api", "method")
+
+var apiErrors = NewCounterVec("google_api_errors_total", "Failed calls (transport errors and non 2xx responses) to the YouTube and Sheets APIs by API and method", "api", "method")
+
+var apiDuration = NewHistogramVec("google_api_call_duration_seconds", "Time taken by calls to the YouTube and Sheets APIs by API and method", nil, "api", "method")
+
+var youtubeQuota = NewCounterVec("youtube_quota_units_total", "YouTube Data API quota units used by method", "method")
+
+// QuotaMeter - Counts the YouTube Data API quota units of the calls made with a context from
+// WithQuotaMeter, so the quota a catalog uses is known while others fetch at the same time
+type QuotaMeter struct {
+	units int64
+}
+
+// Used - Returns the quota units counted so far
+func (m *QuotaMeter) Used() int64 {
+	return atomic.LoadInt64(&m.units)
+}
+
+type quotaMeterKey struct{}
+
+// WithQuotaMeter - Returns a context whose YouTube API calls are counted by m
+func WithQuotaMeter(ctx context.Context, m *QuotaMeter) context.Context {
+	return context.WithValue(ctx, quotaMeterKey{}, m)
+}
+
+// youtubeQuotaCosts - Quota units of YouTube Data API methods that do not cost the default
+// 1 unit of a list call, see https://developers.google.com/youtube/v3/determine_quota_cost
+var youtubeQuotaCosts = map[string]float64{
+	"search.list": 100,
+}
+
+// youtubeWriteCost - Quota units of insert, update and delete calls
+const youtubeWriteCost = 50
+
+// verbs - The API method verb of a resource request by HTTP method
+var verbs = map[string]string{
+	http.MethodPost:   "insert",
+	http.MethodPut:    "update",
+	http.MethodDelete: "delete",
+}
+
+// APIMethod - Names the Google API method of a request like the API reference does, e.g.
+// "youtube", "videos.list" for .../youtube/v3/videos and "sheets", "spreadsheets.values.get"
+// for .../v4/spreadsheets/{id}/values/{range}. The API is "" for other requests
+func APIMethod(r *http.Request) (api string, method string) {
+	path := r.URL.Path
+	if i := strings.Index(path, "/youtube/v3/"); i >= 0 {
+		resource := strings.Trim(path[i+len("/youtube/v3/"):], "/")
+		verb, ok := verbs[r.Method]
+		if !ok {
+			verb = "list"
+		}
+		return "youtube", strings.Replace(resource, "/", ".", -1) + "." + verb
+	}
+	if r.URL.Host == "sheets.googleapis.com" {
+		segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/v4/"), "/"), "/")
+		var names []string
+		verb := "get"
+		// collections and IDs alternate, a custom verb like ":batchGet" can follow the last one
+		// (ranges like "Sheet1!A2:A1000" have colons as well)
+		last := len(segments) - 1
+		if j := strings.LastIndex(segments[last], ":"); j >= 0 && isVerb(segments[last][j+1:]) {
+			verb = segments[last][j+1:]
+			segments[last] = segments[last][:j]
+		}
+		for i := 0; i < len(segments); i += 2 {
+			names = append(names, segments[i])
+		}
+		if verb == "get" && r.Method != http.MethodGet {
+			verb = strings.ToLower(r.Method)
+		}
+		return "sheets", strings.Join(append(names, verb), ".")
+	}
+	return "", ""
+}
+
+// isVerb - Reports whether s looks like a custom method verb ("batchGet", "append")
+func isVerb(s string) bool {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// quotaCost - The YouTube Data API quota units of a method
+func quotaCost(method string) float64 {
+	if cost, ok := youtubeQuotaCosts[method]; ok {
+		return cost
+	}
+	if strings.HasSuffix(method, ".list") {
+		return 1
+	}
+	return youtubeWriteCost
+}
+
+// transport - Counts the calls to Google APIs made through it
+type transport struct {
+	base http.RoundTripper
+}
+
+// Transport - Wraps a round tripper (http.DefaultTransport if nil) to count and time
+// the YouTube and Sheets API calls made through it
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	api, method := APIMethod(r)
+	if api == "" {
+		return t.base.RoundTrip(r)
+	}
+
+	start := time.Now()
+	res, err := t.base.RoundTrip(r)
+	apiCalls.Inc(api, method)
+	apiDuration.Observe(time.Since(start).Seconds(), api, method)
+	if err != nil || res.StatusCode < 200 || res.StatusCode > 299 {
+		apiErrors.Inc(api, method)
+	}
+	// failed calls use quota as well, except those that never reached the API
+	if api == "youtube" && err == nil {
+		cost := quotaCost(method)
+		youtubeQuota.Add(cost, method)
+		if m, ok := r.Context().Value(quotaMeterKey{}).(*QuotaMeter); ok {
+			atomic.AddInt64(&m.units, int64(cost))
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,74 @@
+package random
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Source - A seeded random number generator that is safe for concurrent use
+type Source struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+	seed int64
+}
+
+// NewSource - Returns a source that always produces the same sequence for the same seed
+func NewSource(seed int64) *Source {
+	return &Source{
+		rand: rand.New(rand.NewSource(seed)),
+		seed: seed,
+	}
+}
+
+// Seed - Returns the seed the source was created with
+func (s *Source) Seed() int64 {
+	return s.seed
+}
+
+// Intn - Returns a number in [0, n), panics if n <= 0
+func (s *Source) Intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Intn(n)
+}
+
+// Int63 - Returns a non-negative 63 bit number
+func (s *Source) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Int63()
+}
+
+// Float64 - Returns a number in [0.0, 1.0)
+func (s *Source) Float64() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Float64()
+}
+
+// seeds - generates seeds for requests that do not supply their own
+var seeds = NewSource(time.Now().UnixNano())
+
+// NewSeed - Returns a fresh seed from the shared generator
+func NewSeed() int64 {
+	return seeds.Int63()
+}
+
+// ParseSeed - Parses a seed from a string, generating a new one if the string is empty
+func ParseSeed(s string) (int64, error) {
+	if s == "" {
+		return NewSeed(), nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// FromString - Returns a new source from a seed string (see ParseSeed)
+func FromString(s string) (*Source, error) {
+	seed, err := ParseSeed(s)
+	if err != nil {
+		return nil, err
+	}
+	return NewSource(seed), nil
+}
@@ -0,0 +1,78 @@
+package random
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSourceSequence(t *testing.T) {
+	tests := []struct {
+		seed int64
+		want []int
+	}{
+		{1, []int{1, 7, 7, 9, 1, 8, 5, 0}},
+		{42, []int{5, 7, 8, 0, 3, 5, 7, 6}},
+	}
+	for _, tt := range tests {
+		src := NewSource(tt.seed)
+		got := make([]int, len(tt.want))
+		for i := range got {
+			got[i] = src.Intn(10)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("seed %d: got %v, want %v", tt.seed, got, tt.want)
+		}
+		if src.Seed() != tt.seed {
+			t.Errorf("Seed() = %d, want %d", src.Seed(), tt.seed)
+		}
+	}
+}
+
+func TestParseSeed(t *testing.T) {
+	if seed, err := ParseSeed("123"); err != nil || seed != 123 {
+		t.Errorf("ParseSeed(\"123\") = %d, %v", seed, err)
+	}
+	if _, err := ParseSeed("abc"); err == nil {
+		t.Error("ParseSeed(\"abc\") did not fail")
+	}
+	if _, err := ParseSeed(""); err != nil {
+		t.Errorf("ParseSeed(\"\") failed: %v", err)
+	}
+}
+
+// TestSourceConcurrent - One source shared by many goroutines, run with -race
+func TestSourceConcurrent(t *testing.T) {
+	src := NewSource(1)
+	const goroutines, picks = 8, 1000
+
+	var wg sync.WaitGroup
+	counts := make([][10]int, goroutines)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < picks; i++ {
+				counts[g][src.Intn(10)]++
+				NewSeed()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// the picks are the same as from one goroutine, only their order differs
+	want := [10]int{}
+	seq := NewSource(1)
+	for i := 0; i < goroutines*picks; i++ {
+		want[seq.Intn(10)]++
+	}
+	var got [10]int
+	for _, c := range counts {
+		for i, n := range c {
+			got[i] += n
+		}
+	}
+	if got != want {
+		t.Errorf("got counts %v, want %v", got, want)
+	}
+}
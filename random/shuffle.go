@@ -0,0 +1,175 @@
+package random
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// feistelRounds - Rounds of the Feistel network, four make it a pseudorandom permutation
+const feistelRounds = 4
+
+// mix64 - The splitmix64 finalizer, spreads every input bit over the output
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// feistel - A bijection of [0, 4^halfBits) keyed by the seed: a balanced Feistel network whose
+// round function hashes the right half with a per-round key
+func feistel(x uint64, halfBits uint, seed int64) uint64 {
+	mask := uint64(1)<<halfBits - 1
+	l, r := x>>halfBits, x&mask
+	key := uint64(seed)
+	for round := 0; round < feistelRounds; round++ {
+		key += 0x9e3779b97f4a7c15
+		l, r = r, l^(mix64(r^mix64(key))&mask)
+	}
+	return l<<halfBits | r
+}
+
+// PermutationAt - Returns the element at position k of the permutation of [0, n) generated
+// from seed. The permutation is a seeded Feistel network over the smallest even power of two
+// that holds n, cycle-walked until it lands in [0, n), so no part of it is ever stored and
+// any position costs O(1) time and memory (about 4 rounds on average)
+func PermutationAt(seed int64, n, k int) int {
+	if n <= 0 || k < 0 || k >= n {
+		return -1
+	}
+	halfBits := uint(bits.Len(uint(n-1))+1) / 2
+	if halfBits == 0 {
+		halfBits = 1
+	}
+	x := feistel(uint64(k), halfBits, seed)
+	for x >= uint64(n) {
+		x = feistel(x, halfBits, seed)
+	}
+	return int(x)
+}
+
+// Permutation - Returns the full permutation of [0, n) generated from seed,
+// Permutation(seed, n)[k] == PermutationAt(seed, n, k)
+func Permutation(seed int64, n int) []int {
+	p := make([]int, n)
+	for k := range p {
+		p[k] = PermutationAt(seed, n, k)
+	}
+	return p
+}
+
+// NewToken - Returns a random hex token suitable for identifying a session
+func NewToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// fall back to the seeded generator, tokens only need to be unique
+		for i := range b {
+			b[i] = byte(NewSeed())
+		}
+	}
+	return hex.EncodeToString(b)
+}
+
+// shuffle - A single session's position in its permutation, the element at the cursor is
+// derived from the seed so the session's size does not grow with the catalog or the cursor
+type shuffle struct {
+	token    string
+	seed     int64
+	cursor   int
+	size     int
+	filter   string
+	lastUsed time.Time
+}
+
+// ShuffleSessions - Shuffle bags keyed by session token. Each session walks a permutation
+// of the catalog so nothing repeats until every item has been served
+type ShuffleSessions struct {
+	// TTL - sessions that are not used for this long are forgotten
+	TTL time.Duration
+	// MaxSessions - the least recently used session is dropped once this many exist
+	MaxSessions int
+
+	mu       sync.Mutex
+	sessions map[string]*list.Element
+	// order - the sessions from most to least recently used
+	order *list.List
+}
+
+// NewShuffleSessions - Returns an empty session store
+func NewShuffleSessions(ttl time.Duration, maxSessions int) *ShuffleSessions {
+	return &ShuffleSessions{
+		TTL:         ttl,
+		MaxSessions: maxSessions,
+		sessions:    make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// Next - Returns the session token (a new one if token is empty) and the index
+// of the next item out of n, or -1 if n is 0. The filter identifies which subset of the
+// catalog n refers to, if it or n changes the session starts a new permutation
+func (s *ShuffleSessions) Next(token string, n int, filter string) (string, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if token == "" {
+		token = NewToken()
+	}
+	var sess *shuffle
+	if e, ok := s.sessions[token]; ok {
+		sess = e.Value.(*shuffle)
+		s.order.MoveToFront(e)
+	} else {
+		s.evict()
+		sess = &shuffle{token: token}
+		s.sessions[token] = s.order.PushFront(sess)
+	}
+	sess.lastUsed = now
+
+	if n == 0 {
+		return token, -1
+	}
+	if sess.size != n || sess.filter != filter || sess.cursor >= sess.size {
+		// new session, the catalog changed or every item was served, start a new permutation
+		sess.seed, sess.cursor, sess.size, sess.filter = NewSeed(), 0, n, filter
+	}
+	index := PermutationAt(sess.seed, sess.size, sess.cursor)
+	sess.cursor++
+	return token, index
+}
+
+// Len - Returns the number of live sessions
+func (s *ShuffleSessions) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// sweep - Drops expired sessions, which are at the back of the order
+func (s *ShuffleSessions) sweep(now time.Time) {
+	if s.TTL <= 0 {
+		return
+	}
+	for e := s.order.Back(); e != nil && now.Sub(e.Value.(*shuffle).lastUsed) > s.TTL; e = s.order.Back() {
+		s.remove(e)
+	}
+}
+
+// evict - Makes room for one more session by dropping the least recently used one
+func (s *ShuffleSessions) evict() {
+	if s.MaxSessions <= 0 || len(s.sessions) < s.MaxSessions {
+		return
+	}
+	s.remove(s.order.Back())
+}
+
+func (s *ShuffleSessions) remove(e *list.Element) {
+	s.order.Remove(e)
+	delete(s.sessions, e.Value.(*shuffle).token)
+}
@@ -0,0 +1,120 @@
+package random
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPermutation(t *testing.T) {
+	if got, want := Permutation(3, 10), []int{9, 1, 4, 6, 3, 5, 2, 0, 7, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Permutation(3, 10) = %v, want %v", got, want)
+	}
+
+	// every element exactly once, and PermutationAt agrees with Permutation
+	for _, seed := range []int64{1, 3, 42, -7} {
+		for _, n := range []int{1, 2, 3, 10, 100, 1000} {
+			p := Permutation(seed, n)
+			seen := make([]bool, n)
+			for k, v := range p {
+				if v < 0 || v >= n || seen[v] {
+					t.Fatalf("seed %d, n %d: element %d is %d, out of range or repeated", seed, n, k, v)
+				}
+				seen[v] = true
+				if got := PermutationAt(seed, n, k); got != v {
+					t.Fatalf("PermutationAt(%d, %d, %d) = %d, want %d", seed, n, k, got, v)
+				}
+			}
+		}
+	}
+
+	for _, k := range []int{-1, 5} {
+		if got := PermutationAt(1, 5, k); got != -1 {
+			t.Errorf("PermutationAt(1, 5, %d) = %d, want -1", k, got)
+		}
+	}
+}
+
+// TestShuffleSessionsMemory - Advancing a session allocates nothing, so its memory stays
+// the same however far the cursor gets and however large the catalog is
+func TestShuffleSessionsMemory(t *testing.T) {
+	s := NewShuffleSessions(time.Hour, 10)
+	const n = 1000000
+	token, _ := s.Next("", n, "all")
+
+	allocs := testing.AllocsPerRun(10000, func() {
+		s.Next(token, n, "all")
+	})
+	if allocs != 0 {
+		t.Errorf("Next allocated %v times per call, want 0", allocs)
+	}
+	if cursor := s.sessions[token].Value.(*shuffle).cursor; cursor < 10000 {
+		t.Errorf("cursor is %d, want at least 10000", cursor)
+	}
+}
+
+func TestShuffleSessionsNext(t *testing.T) {
+	s := NewShuffleSessions(time.Hour, 10)
+	token, _ := s.Next("", 0, "")
+	if token == "" {
+		t.Fatal("Next did not return a token")
+	}
+
+	// every item once per round, then a new round
+	for round := 0; round < 2; round++ {
+		var got []int
+		for i := 0; i < 7; i++ {
+			var index int
+			token, index = s.Next(token, 7, "all")
+			got = append(got, index)
+		}
+		sort.Ints(got)
+		if want := []int{0, 1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(got, want) {
+			t.Errorf("round %d served %v, want every index once", round, got)
+		}
+	}
+
+	if _, index := s.Next(token, 0, "all"); index != -1 {
+		t.Errorf("Next with n 0 = %d, want -1", index)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", s.Len())
+	}
+}
+
+func TestShuffleSessionsEvict(t *testing.T) {
+	s := NewShuffleSessions(time.Hour, 3)
+	s.Next("a", 5, "")
+	s.Next("b", 5, "")
+	s.Next("c", 5, "")
+	// a is used again, so b is the least recently used
+	s.Next("a", 5, "")
+	s.Next("d", 5, "")
+
+	if s.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", s.Len())
+	}
+	for _, token := range []string{"a", "c", "d"} {
+		if _, ok := s.sessions[token]; !ok {
+			t.Errorf("session %s was dropped", token)
+		}
+	}
+	if _, ok := s.sessions["b"]; ok {
+		t.Error("session b was kept")
+	}
+}
+
+func TestShuffleSessionsExpire(t *testing.T) {
+	s := NewShuffleSessions(time.Millisecond, 0)
+	s.Next("a", 5, "")
+	time.Sleep(5 * time.Millisecond)
+	s.Next("b", 5, "")
+
+	if _, ok := s.sessions["a"]; ok {
+		t.Error("expired session a was kept")
+	}
+	if s.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", s.Len())
+	}
+}
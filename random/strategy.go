@@ -0,0 +1,244 @@
+package random
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pool - A list of items that a Strategy can pick from
+type Pool interface {
+	// Len - number of items in the pool
+	Len() int
+	// ID - a stable identifier for the item at index i
+	ID(i int) string
+	// Source - the playlist, channel or sheet row the item came from
+	Source(i int) string
+	// Weight - the curator assigned weight of the item's source
+	Weight(i int) float64
+	// Published - when the item was published (zero if unknown)
+	Published(i int) time.Time
+}
+
+// Strategy - Picks the index of an item from a pool, or -1 if nothing can be picked
+type Strategy interface {
+	Name() string
+	Pick(src *Source, pool Pool) int
+}
+
+// StrategySet - Strategies by name. Strategies that keep a history, like lru, have their own
+// in every set, so each catalog gets a set of its own
+type StrategySet map[string]Strategy
+
+// NewStrategySet - Returns every strategy, with an empty history
+func NewStrategySet() StrategySet {
+	set := StrategySet{}
+	for _, s := range []Strategy{Uniform{}, UniformSource{}, SourceWeighted{}, Recency{HalfLife: 365 * 24 * time.Hour}, NewLeastRecentlyServed()} {
+		set[s.Name()] = s
+	}
+	return set
+}
+
+// Strategies - All available strategies by name
+var Strategies = NewStrategySet()
+
+// DefaultStrategy - The name of the strategy used when a request does not ask for one
+var DefaultStrategy = Uniform{}.Name()
+
+// StrategyNames - Returns the sorted names of all strategies
+func StrategyNames() []string {
+	names := make([]string, 0, len(Strategies))
+	for name := range Strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ByName - Looks up a strategy of the set by name, an empty name returns the DefaultStrategy
+func (set StrategySet) ByName(name string) (Strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	s, ok := set[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q (available: %s)", name, strings.Join(StrategyNames(), ", "))
+	}
+	return s, nil
+}
+
+// StrategyByName - Looks up a strategy of Strategies by name, an empty name returns the DefaultStrategy
+func StrategyByName(name string) (Strategy, error) {
+	return Strategies.ByName(name)
+}
+
+// SetDefaultStrategy - Sets the DefaultStrategy by name
+func SetDefaultStrategy(name string) error {
+	s, err := StrategyByName(name)
+	if err != nil {
+		return err
+	}
+	DefaultStrategy = s.Name()
+	return nil
+}
+
+// pickWeighted - Picks an index with probability proportional to its weight,
+// falls back to a uniform pick if no weight is positive
+func pickWeighted(src *Source, weights []float64) int {
+	if len(weights) == 0 {
+		return -1
+	}
+	cumulative := make([]float64, len(weights))
+	total := 0.0
+	for i, w := range weights {
+		if w > 0 && !math.IsInf(w, 0) && !math.IsNaN(w) {
+			total += w
+		}
+		cumulative[i] = total
+	}
+	if total == 0 {
+		return src.Intn(len(weights))
+	}
+	target := src.Float64() * total
+	return sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > target })
+}
+
+// groupBySource - Returns the indexes of the pool grouped by source, in order of first appearance
+func groupBySource(pool Pool) (sources []string, groups map[string][]int) {
+	groups = make(map[string][]int)
+	for i := 0; i < pool.Len(); i++ {
+		s := pool.Source(i)
+		if _, ok := groups[s]; !ok {
+			sources = append(sources, s)
+		}
+		groups[s] = append(groups[s], i)
+	}
+	return sources, groups
+}
+
+// Uniform - Every item in the pool is equally likely
+type Uniform struct{}
+
+// Name - "uniform"
+func (Uniform) Name() string { return "uniform" }
+
+// Pick - Picks any item with equal probability
+func (Uniform) Pick(src *Source, pool Pool) int {
+	if pool.Len() == 0 {
+		return -1
+	}
+	return src.Intn(pool.Len())
+}
+
+// UniformSource - Every source is equally likely, then every item within it
+type UniformSource struct{}
+
+// Name - "source"
+func (UniformSource) Name() string { return "source" }
+
+// Pick - Picks a source with equal probability, then an item from that source
+func (UniformSource) Pick(src *Source, pool Pool) int {
+	sources, groups := groupBySource(pool)
+	if len(sources) == 0 {
+		return -1
+	}
+	group := groups[sources[src.Intn(len(sources))]]
+	return group[src.Intn(len(group))]
+}
+
+// SourceWeighted - Sources are picked in proportion to their curator weight,
+// then every item within the source is equally likely
+type SourceWeighted struct{}
+
+// Name - "weighted"
+func (SourceWeighted) Name() string { return "weighted" }
+
+// Pick - Picks a source by weight, then an item from that source
+func (SourceWeighted) Pick(src *Source, pool Pool) int {
+	sources, groups := groupBySource(pool)
+	if len(sources) == 0 {
+		return -1
+	}
+	weights := make([]float64, len(sources))
+	for i, s := range sources {
+		weights[i] = pool.Weight(groups[s][0])
+	}
+	group := groups[sources[pickWeighted(src, weights)]]
+	return group[src.Intn(len(group))]
+}
+
+// Recency - Newer items are more likely, an item's weight halves every HalfLife
+type Recency struct {
+	HalfLife time.Duration
+	// Now - returns the current time, defaults to time.Now
+	Now func() time.Time
+}
+
+// Name - "recent"
+func (Recency) Name() string { return "recent" }
+
+// Pick - Picks an item weighted by how recently it was published
+func (s Recency) Pick(src *Source, pool Pool) int {
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+	weights := make([]float64, pool.Len())
+	for i := range weights {
+		published := pool.Published(i)
+		if published.IsZero() {
+			continue
+		}
+		age := now.Sub(published)
+		if age < 0 {
+			age = 0
+		}
+		weights[i] = math.Exp2(-float64(age) / float64(s.HalfLife))
+	}
+	return pickWeighted(src, weights)
+}
+
+// LeastRecentlyServed - Picks among the items that were served the longest time ago
+// (or never), so repeats only happen once everything has been served
+type LeastRecentlyServed struct {
+	mu     sync.Mutex
+	clock  uint64
+	served map[string]uint64
+}
+
+// NewLeastRecentlyServed - Returns a strategy with an empty serving history
+func NewLeastRecentlyServed() *LeastRecentlyServed {
+	return &LeastRecentlyServed{served: make(map[string]uint64)}
+}
+
+// Name - "lru"
+func (*LeastRecentlyServed) Name() string { return "lru" }
+
+// Pick - Picks uniformly among the least recently served items and records the pick
+func (s *LeastRecentlyServed) Pick(src *Source, pool Pool) int {
+	if pool.Len() == 0 {
+		return -1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var oldest []int
+	var oldestTick uint64
+	for i := 0; i < pool.Len(); i++ {
+		tick := s.served[pool.ID(i)]
+		if len(oldest) == 0 || tick < oldestTick {
+			oldest, oldestTick = oldest[:0], tick
+		}
+		if tick == oldestTick {
+			oldest = append(oldest, i)
+		}
+	}
+
+	pick := oldest[src.Intn(len(oldest))]
+	s.clock++
+	s.served[pool.ID(pick)] = s.clock
+	return pick
+}
@@ -0,0 +1,137 @@
+package random
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testPool - A pool from slices
+type testPool struct {
+	ids       []string
+	sources   []string
+	weights   []float64
+	published []time.Time
+}
+
+func (p testPool) Len() int                  { return len(p.ids) }
+func (p testPool) ID(i int) string           { return p.ids[i] }
+func (p testPool) Source(i int) string       { return p.sources[i] }
+func (p testPool) Weight(i int) float64      { return p.weights[i] }
+func (p testPool) Published(i int) time.Time { return p.published[i] }
+
+var testNow = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestPool - Four items of a playlist, one of a weighted playlist and an unweighted
+// sheet video without a publish date
+func newTestPool() testPool {
+	return testPool{
+		ids:     []string{"a", "b", "c", "d", "e", "f"},
+		sources: []string{"p1", "p1", "p1", "p1", "p2", "v"},
+		weights: []float64{1, 1, 1, 1, 3, 0},
+		published: []time.Time{
+			testNow.AddDate(-10, 0, 0), testNow.AddDate(-5, 0, 0), testNow.AddDate(-1, 0, 0),
+			testNow.AddDate(0, -1, 0), testNow.AddDate(0, 0, -1), {},
+		},
+	}
+}
+
+func TestStrategyPicks(t *testing.T) {
+	tests := []struct {
+		strategy Strategy
+		want     []int
+	}{
+		{Uniform{}, []int{2, 0, 3, 3, 2, 2, 4, 4, 4, 0, 2, 1}},
+		{UniformSource{}, []int{5, 3, 5, 4, 4, 5, 5, 4, 4, 5, 2, 3}},
+		// the sheet video has weight 0 and is never picked
+		{SourceWeighted{}, []int{4, 3, 4, 4, 0, 4, 2, 0, 4, 4, 4, 4}},
+		// the video without a publish date is never picked, the older ones rarely
+		{Recency{HalfLife: 365 * 24 * time.Hour, Now: func() time.Time { return testNow }}, []int{4, 3, 3, 4, 4, 2, 3, 3, 2, 4, 3, 3}},
+		// every item once before any repeats
+		{NewLeastRecentlyServed(), []int{2, 0, 3, 1, 4, 5, 2, 0, 3, 1, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.Name(), func(t *testing.T) {
+			src, pool := NewSource(7), newTestPool()
+			got := make([]int, len(tt.want))
+			for i := range got {
+				got[i] = tt.strategy.Pick(src, pool)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrategyEmptyPool(t *testing.T) {
+	for name, s := range Strategies {
+		if got := s.Pick(NewSource(1), testPool{}); got != -1 {
+			t.Errorf("%s: Pick on an empty pool = %d, want -1", name, got)
+		}
+	}
+}
+
+func TestPickWeighted(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []float64
+		want    []int
+	}{
+		{"only one positive", []float64{0, 2, 0}, []int{1, 1, 1, 1, 1}},
+		{"none positive falls back to uniform", []float64{0, 0, 0}, []int{2, 0, 2, 2, 1}},
+		{"empty", nil, []int{-1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := NewSource(1)
+			got := make([]int, len(tt.want))
+			for i := range got {
+				got[i] = pickWeighted(src, tt.weights)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrategyByName(t *testing.T) {
+	if s, err := StrategyByName(""); err != nil || s.Name() != DefaultStrategy {
+		t.Errorf("StrategyByName(\"\") = %v, %v, want the default", s, err)
+	}
+	for _, name := range StrategyNames() {
+		if s, err := StrategyByName(name); err != nil || s.Name() != name {
+			t.Errorf("StrategyByName(%q) = %v, %v", name, s, err)
+		}
+	}
+	if _, err := StrategyByName("nope"); err == nil {
+		t.Error("StrategyByName(\"nope\") did not fail")
+	}
+}
+
+// TestStrategySetHistory - Every set has its own lru history, so picks for one catalog do
+// not make the other catalog's items look served
+func TestStrategySetHistory(t *testing.T) {
+	a, b := NewStrategySet(), NewStrategySet()
+	lruA, _ := a.ByName("lru")
+	lruB, _ := b.ByName("lru")
+	if lruA == lruB {
+		t.Fatal("two sets share one lru strategy")
+	}
+
+	pool := newTestPool()
+	src := NewSource(7)
+	for i := 0; i < pool.Len(); i++ {
+		lruA.Pick(src, pool)
+	}
+	// b has served nothing, so its picks are the same as those of a new strategy
+	got, want := make([]int, pool.Len()), make([]int, pool.Len())
+	srcB, srcNew, fresh := NewSource(3), NewSource(3), NewLeastRecentlyServed()
+	for i := range got {
+		got[i], want[i] = lruB.Pick(srcB, pool), fresh.Pick(srcNew, pool)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("set b picked %v, a new history picks %v", got, want)
+	}
+}
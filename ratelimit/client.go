@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// APIKeyHeader - Request header with a client API key
+const APIKeyHeader = "X-API-Key"
+
+var (
+	mu             sync.RWMutex
+	trustedProxies []*net.IPNet
+	apiKeys        = make(map[string]string)
+)
+
+// ParseCIDRs - Parses comma separated CIDRs or single IPs like "10.0.0.0/8,127.0.0.1"
+func ParseCIDRs(spec string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", s)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			s = fmt.Sprintf("%s/%d", s, bits)
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy range %q", s)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// SetTrustedProxies - Sets the proxies whose X-Forwarded-For header is trusted
+func SetTrustedProxies(nets []*net.IPNet) {
+	mu.Lock()
+	defer mu.Unlock()
+	trustedProxies = nets
+}
+
+// ParseAPIKeys - Parses comma separated client keys in the form name:key
+func ParseAPIKeys(spec string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		parts := strings.SplitN(s, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("client API key %q is not in the form name:key", parts[0])
+		}
+		keys[parts[1]] = parts[0]
+	}
+	return keys, nil
+}
+
+// SetAPIKeys - Sets the client API keys (key to name) that get their own budget
+func SetAPIKeys(keys map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	apiKeys = keys
+}
+
+// trusted - Reports whether an IP belongs to a trusted proxy
+func trusted(ip net.IP) bool {
+	for _, n := range trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP - Returns the IP of the client. X-Forwarded-For is only followed through
+// trusted proxies, from the right, so clients can not spoof their address
+func ClientIP(r *http.Request) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !trusted(ip) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !trusted(hop) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+// ClientKey - Returns the key requests are counted by: the name of a known
+// API key, otherwise the client IP
+func ClientKey(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		mu.RLock()
+		name, ok := apiKeys[key]
+		mu.RUnlock()
+		if ok {
+			return "key:" + name
+		}
+	}
+	return "ip:" + ClientIP(r)
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseCIDRs("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetTrustedProxies(proxies)
+	defer SetTrustedProxies(nil)
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"no proxy", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"untrusted peer is not followed", "203.0.113.5:1234", []string{"198.51.100.1"}, "203.0.113.5"},
+		{"one trusted proxy", "10.0.0.1:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		// the client can put anything on the left, the first untrusted hop from the right counts
+		{"spoofed hops on the left", "10.0.0.1:1234", []string{"1.2.3.4, 198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"header lines are joined", "10.0.0.1:1234", []string{"1.2.3.4", "198.51.100.1, 192.168.1.1"}, "198.51.100.1"},
+		{"only trusted hops", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"garbage stops the walk", "10.0.0.1:1234", []string{"198.51.100.1, nonsense, 10.0.0.2"}, "10.0.0.2"},
+		{"no header", "10.0.0.1:1234", nil, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/random", nil)
+			r.RemoteAddr = tt.remote
+			for _, f := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", f)
+			}
+			if got := ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	SetAPIKeys(map[string]string{"k3y": "bot"})
+	defer SetAPIKeys(map[string]string{})
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/random", nil)
+	r.RemoteAddr = "203.0.113.5:1234"
+	if got := ClientKey(r); got != "ip:203.0.113.5" {
+		t.Errorf("without a key: %s", got)
+	}
+	r.Header.Set(APIKeyHeader, "unknown")
+	if got := ClientKey(r); got != "ip:203.0.113.5" {
+		t.Errorf("with an unknown key: %s", got)
+	}
+	r.Header.Set(APIKeyHeader, "k3y")
+	if got := ClientKey(r); got != "key:bot" {
+		t.Errorf("with a known key: %s", got)
+	}
+}
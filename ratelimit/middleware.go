@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/apierror"
+)
+
+// Budgets - The limiters that routes are assigned to, a missing budget is unlimited
+var Budgets = map[string]*Limiter{
+	"random": NewLimiter(Limit{Rate: 10, Burst: 30}),
+	"list":   NewLimiter(Limit{Rate: 1, Burst: 10}),
+	"admin":  NewLimiter(Limit{Rate: 0.5, Burst: 10}),
+}
+
+// ParseBudgets - Parses a spec like "random=10/s:30,list=off", "off" gives a nil limit
+func ParseBudgets(spec string) (map[string]*Limit, error) {
+	budgets := make(map[string]*Limit)
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		parts := strings.SplitN(s, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("rate limit %q is not in the form budget=count/unit[:burst]", s)
+		}
+		if parts[1] == "off" {
+			budgets[parts[0]] = nil
+			continue
+		}
+		l, err := ParseLimit(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		budgets[parts[0]] = &l
+	}
+	return budgets, nil
+}
+
+// SetBudgets - Changes budgets from a spec like "random=10/s:30,list=off", "off" removes the limit
+func SetBudgets(spec string) error {
+	budgets, err := ParseBudgets(spec)
+	if err != nil {
+		return err
+	}
+	for name, l := range budgets {
+		if l == nil {
+			delete(Budgets, name)
+		} else {
+			Budgets[name] = NewLimiter(*l)
+		}
+	}
+	return nil
+}
+
+// ceilSeconds - Rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Middleware - Counts requests against a budget per client, answering 429 when the client's
+// bucket is empty. Every response gets RateLimit-* headers
+func Middleware(budget string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l, ok := Budgets[budget]
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		res := l.Allow(budget+"|"+ClientKey(r), time.Now())
+		h := w.Header()
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s;name=%q", l.Burst, ceilSeconds(seconds(float64(l.Burst)/l.Rate)), budget))
+		h.Set("RateLimit-Limit", strconv.Itoa(l.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+		if !res.Allowed {
+			h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+			apierror.Write(w, r, http.StatusTooManyRequests, fmt.Sprintf("Rate limit of the %s budget exceeded, retry in %s seconds", budget, ceilSeconds(res.RetryAfter)))
+			return
+		}
+		next(w, r)
+	}
+}
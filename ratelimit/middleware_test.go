@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	prev := Budgets
+	Budgets = map[string]*Limiter{"test": NewLimiter(Limit{Rate: 0.001, Burst: 2})}
+	defer func() { Budgets = prev }()
+
+	h := Middleware("test", func(w http.ResponseWriter, r *http.Request) {})
+	unlimited := Middleware("other", func(w http.ResponseWriter, r *http.Request) {})
+	request := func(remote string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/random", nil)
+		r.RemoteAddr = remote
+		return r
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		h(w, request("203.0.113.5:1234"))
+		if w.Code != want {
+			t.Errorf("request %d: status %d, want %d", i, w.Code, want)
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("request %d: RateLimit-Limit %q", i, w.Header().Get("RateLimit-Limit"))
+		}
+		if want == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("request %d: 429 without Retry-After", i)
+		}
+	}
+
+	// another client has its own bucket, a route without a budget is not limited
+	w := httptest.NewRecorder()
+	h(w, request("203.0.113.6:1234"))
+	if w.Code != http.StatusOK {
+		t.Errorf("other client: status %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	unlimited(w, request("203.0.113.5:1234"))
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("unlimited route: status %d, RateLimit-Limit %q", w.Code, w.Header().Get("RateLimit-Limit"))
+	}
+}
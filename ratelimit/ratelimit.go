@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit - A token bucket that refills Rate tokens per second up to Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// units - The rate units ParseLimit understands
+var units = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// ParseLimit - Parses a limit like "10/s:30" (10 per second, bursts of 30) or "60/m" (burst defaults to the count)
+func ParseLimit(s string) (Limit, error) {
+	rate, burst := s, ""
+	if i := strings.Index(s, ":"); i >= 0 {
+		rate, burst = s[:i], s[i+1:]
+	}
+	parts := strings.Split(rate, "/")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("rate limit %q is not in the form count/unit[:burst], e.g. 10/s:30", s)
+	}
+	count, err := strconv.Atoi(parts[0])
+	unit, ok := units[parts[1]]
+	if err != nil || count < 1 || !ok {
+		return Limit{}, fmt.Errorf("rate limit %q is not in the form count/unit[:burst] with a unit of s, m or h", s)
+	}
+
+	l := Limit{Rate: float64(count) / unit.Seconds(), Burst: count}
+	if burst != "" {
+		if l.Burst, err = strconv.Atoi(burst); err != nil || l.Burst < 1 {
+			return Limit{}, fmt.Errorf("rate limit %q has an invalid burst", s)
+		}
+	}
+	return l, nil
+}
+
+// String - Formats the limit like ParseLimit expects it
+func (l Limit) String() string {
+	switch {
+	case l.Rate >= 1:
+		return fmt.Sprintf("%g/s:%d", l.Rate, l.Burst)
+	case l.Rate*60 >= 1:
+		return fmt.Sprintf("%g/m:%d", l.Rate*60, l.Burst)
+	}
+	return fmt.Sprintf("%g/h:%d", l.Rate*3600, l.Burst)
+}
+
+// bucket - The tokens of one client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter - Keeps a token bucket per client key
+type Limiter struct {
+	Limit
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter - Returns a limiter where every key starts with a full bucket
+func NewLimiter(l Limit) *Limiter {
+	return &Limiter{Limit: l, buckets: make(map[string]*bucket)}
+}
+
+// Result - The outcome of taking a token
+type Result struct {
+	Allowed bool
+	// Remaining - whole tokens left after the request
+	Remaining int
+	// Reset - how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter - how long until the next token, zero if the request was allowed
+	RetryAfter time.Duration
+}
+
+// refill - Adds the tokens earned since the bucket was last used
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+	b.last = now
+}
+
+// Allow - Takes a token from the key's bucket if there is one
+func (l *Limiter) Allow(key string, now time.Time) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+
+	var res Result
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / l.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((float64(l.Burst) - b.tokens) / l.Rate)
+	return res
+}
+
+// sweep - Drops buckets that have refilled completely at most once a minute,
+// a new bucket starts full so they are not needed anymore
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		l.refill(b, now)
+		if b.tokens >= float64(l.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Len - Returns the number of clients with a bucket
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// seconds - Converts float seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
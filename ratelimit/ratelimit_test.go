@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestLimiterBurstAndRefill(t *testing.T) {
+	l := NewLimiter(Limit{Rate: 2, Burst: 3})
+
+	steps := []struct {
+		at      time.Duration
+		allowed bool
+		left    int
+	}{
+		// a new client gets the whole burst
+		{0, true, 2},
+		{0, true, 1},
+		{0, true, 0},
+		{0, false, 0},
+		// 2 tokens per second, half a second earns one
+		{500 * time.Millisecond, true, 0},
+		{500 * time.Millisecond, false, 0},
+		// refilling stops at the burst
+		{time.Hour, true, 2},
+	}
+	for i, s := range steps {
+		res := l.Allow("a", t0.Add(s.at))
+		if res.Allowed != s.allowed || res.Remaining != s.left {
+			t.Errorf("step %d: allowed %v with %d left, want %v with %d", i, res.Allowed, res.Remaining, s.allowed, s.left)
+		}
+		if !res.Allowed && res.RetryAfter != 500*time.Millisecond {
+			t.Errorf("step %d: retry after %s, want 500ms", i, res.RetryAfter)
+		}
+	}
+
+	// other keys have their own bucket
+	if res := l.Allow("b", t0); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("key b: allowed %v with %d left", res.Allowed, res.Remaining)
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	// a used token takes 45 seconds to come back
+	l := NewLimiter(Limit{Rate: 1.0 / 45, Burst: 100})
+	l.Allow("a", t0)
+	l.Allow("b", t0.Add(30*time.Second))
+	if l.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", l.Len())
+	}
+
+	// a minute later a has refilled and is dropped, b still misses tokens
+	l.Allow("c", t0.Add(time.Minute))
+	if _, ok := l.buckets["a"]; ok || l.Len() != 2 {
+		t.Errorf("after the first sweep: %d buckets, a kept %v", l.Len(), ok)
+	}
+	// sweeps run at most once a minute
+	l.Allow("d", t0.Add(time.Minute+time.Second))
+	if l.Len() != 3 {
+		t.Errorf("Len() = %d, want 3 before the next sweep", l.Len())
+	}
+	l.Allow("e", t0.Add(time.Hour))
+	if l.Len() != 1 {
+		t.Errorf("Len() = %d, want only the new bucket after every bucket refilled", l.Len())
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Limit
+		wantErr bool
+	}{
+		{"10/s:30", Limit{Rate: 10, Burst: 30}, false},
+		{"60/m", Limit{Rate: 1, Burst: 60}, false},
+		{"10", Limit{}, true},
+		{"0/s", Limit{}, true},
+		{"10/d", Limit{}, true},
+		{"10/s:0", Limit{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.spec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %v, %v", tt.spec, got, err)
+		}
+	}
+}
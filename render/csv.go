@@ -0,0 +1,90 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// flatten - Adds every scalar in v to row, keyed by its dotted path
+// (e.g. "items.0.snippet.title")
+func flatten(prefix string, v interface{}, row map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			flatten(join(k), child, row)
+		}
+	case []interface{}:
+		for i, child := range t {
+			flatten(join(strconv.Itoa(i)), child, row)
+		}
+	case nil:
+		// null fields are left out, they read the same as missing ones
+	case string:
+		row[prefix] = t
+	case json.Number:
+		row[prefix] = t.String()
+	default:
+		row[prefix] = fmt.Sprintf("%v", t)
+	}
+}
+
+// encodeCSV - Writes a list as one row per element, anything else as a single row.
+// The header is the sorted set of flattened field names across all rows
+func encodeCSV(w io.Writer, v interface{}, opts Options) error {
+	g, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+
+	elements, ok := g.([]interface{})
+	if !ok {
+		elements = []interface{}{g}
+	}
+
+	rows := make([]map[string]string, len(elements))
+	columns := make(map[string]bool)
+	for i, element := range elements {
+		rows[i] = make(map[string]string)
+		prefix := ""
+		if _, isObject := element.(map[string]interface{}); !isObject {
+			prefix = "value"
+		}
+		flatten(prefix, element, rows[i])
+		for column := range rows[i] {
+			columns[column] = true
+		}
+	}
+
+	header := make([]string, 0, len(columns))
+	for column := range columns {
+		header = append(header, column)
+	}
+	sort.Strings(header)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(header))
+	for _, row := range rows {
+		for i, column := range header {
+			record[i] = row[column]
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
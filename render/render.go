@@ -0,0 +1,218 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/httpcache"
+)
+
+// Options - How a value should be encoded
+type Options struct {
+	// Pretty - indent JSON and XML output
+	Pretty bool
+}
+
+// Format - A response format that a value can be encoded in
+type Format struct {
+	Name        string
+	ContentType string
+	// MediaTypes - the Accept media types that select this format
+	MediaTypes []string
+	Encode     func(w io.Writer, v interface{}, opts Options) error
+}
+
+// JSON - JSON encoded value, pretty printed unless ?pretty=false
+var JSON = Format{
+	Name:        "json",
+	ContentType: "application/json",
+	MediaTypes:  []string{"application/json", "text/json"},
+	Encode:      encodeJSON,
+}
+
+// NDJSON - Newline delimited JSON, lists are streamed one element per line
+var NDJSON = Format{
+	Name:        "ndjson",
+	ContentType: "application/x-ndjson",
+	MediaTypes:  []string{"application/x-ndjson", "application/ndjson", "application/jsonl"},
+	Encode:      encodeNDJSON,
+}
+
+// CSV - Comma separated values, lists have one row per element with nested fields flattened
+var CSV = Format{
+	Name:        "csv",
+	ContentType: "text/csv; charset=utf-8",
+	MediaTypes:  []string{"text/csv"},
+	Encode:      encodeCSV,
+}
+
+// XML - The JSON structure of a value as XML elements
+var XML = Format{
+	Name:        "xml",
+	ContentType: "application/xml; charset=utf-8",
+	MediaTypes:  []string{"application/xml", "text/xml"},
+	Encode:      encodeXML,
+}
+
+// Formats - All supported formats, the first one is the default
+var Formats = []Format{JSON, NDJSON, CSV, XML}
+
+// FormatByName - Returns the format for a ?format= value
+func FormatByName(name string) (Format, bool) {
+	for _, f := range Formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// acceptRange - A media range from an Accept header
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept - Returns the media ranges of an Accept header, most preferred first
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(qs, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
+
+// Negotiate - Picks the response format from the "format" query parameter, or else the
+// Accept header. Anything unrecognized in the Accept header falls back to JSON, but an
+// unknown ?format= is an error
+func Negotiate(r *http.Request) (Format, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		f, ok := FormatByName(name)
+		if !ok {
+			names := make([]string, len(Formats))
+			for i, f := range Formats {
+				names[i] = f.Name
+			}
+			return Format{}, fmt.Errorf("unknown format %q (available: %s)", name, strings.Join(names, ", "))
+		}
+		return f, nil
+	}
+
+	for _, ar := range parseAccept(r.Header.Get("Accept")) {
+		if ar.mediaType == "*/*" || ar.mediaType == "application/*" {
+			return JSON, nil
+		}
+		for _, f := range Formats {
+			for _, mt := range f.MediaTypes {
+				if mt == ar.mediaType {
+					return f, nil
+				}
+			}
+		}
+	}
+	return JSON, nil
+}
+
+// OptionsFromRequest - Reads encoding options from the query parameters
+func OptionsFromRequest(r *http.Request) Options {
+	pretty := true
+	if p, err := strconv.ParseBool(r.URL.Query().Get("pretty")); err == nil {
+		pretty = p
+	}
+	return Options{Pretty: pretty}
+}
+
+// Write - Encodes v in the negotiated format directly to the response
+func Write(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	f, err := Negotiate(r)
+	if err != nil {
+		apierror.WriteDetails(w, r, http.StatusNotAcceptable, err.Error(), apierror.Param("format"))
+		return
+	}
+
+	w.Header().Set("Content-Type", f.ContentType)
+	httpcache.Vary(w.Header(), "Accept")
+	w.WriteHeader(status)
+
+	// the status has been sent already, so errors can only be logged
+	if err := f.Encode(w, v, OptionsFromRequest(r)); err != nil {
+		slog.ErrorContext(r.Context(), "could not encode response", "format", f.Name, "path", r.URL.Path, "err", err)
+	}
+}
+
+// Render - Encodes v in a format to a byte slice
+func Render(f Format, v interface{}, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := f.Encode(&buf, v, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeJSON(w io.Writer, v interface{}, opts Options) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if opts.Pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// encodeNDJSON - Writes each element of a list on its own line, flushing as it goes,
+// anything that is not a list is written as a single line
+func encodeNDJSON(w io.Writer, v interface{}, opts Options) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return enc.Encode(v)
+	}
+
+	flusher, _ := w.(http.Flusher)
+	for i := 0; i < rv.Len(); i++ {
+		if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
+
+// toGeneric - Converts a value to the maps, slices and scalars of its JSON form
+func toGeneric(v interface{}) (interface{}, error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.UseNumber()
+	var g interface{}
+	err = dec.Decode(&g)
+	return g, err
+}
@@ -0,0 +1,130 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"unicode"
+)
+
+// RootElement - The name of the outermost XML element
+const RootElement = "response"
+
+// ItemElement - The name of the elements that list items are wrapped in
+const ItemElement = "item"
+
+// validName - Reports whether a JSON key can be used as an XML element name as is
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if unicode.IsLetter(r) || r == '_' {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// xmlWriter - Converts a stream of JSON tokens into XML elements, keeping the key order
+type xmlWriter struct {
+	dec *json.Decoder
+	enc *xml.Encoder
+}
+
+// element - Returns the start element for a key, keys that are not valid XML names
+// become <entry key="...">
+func element(name string) xml.StartElement {
+	if validName(name) {
+		return xml.StartElement{Name: xml.Name{Local: name}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+	}
+}
+
+// value - Writes the next JSON value from the decoder wrapped in start
+func (x *xmlWriter) value(start xml.StartElement) error {
+	tok, err := x.dec.Token()
+	if err != nil {
+		return err
+	}
+	if err := x.enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			for x.dec.More() {
+				keyTok, err := x.dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := x.value(element(keyTok.(string))); err != nil {
+					return err
+				}
+			}
+		case '[':
+			for x.dec.More() {
+				if err := x.value(element(ItemElement)); err != nil {
+					return err
+				}
+			}
+		}
+		// consume the closing delimiter
+		if _, err := x.dec.Token(); err != nil {
+			return err
+		}
+	case nil:
+		// null becomes an empty element
+	case string:
+		if err := x.enc.EncodeToken(xml.CharData(t)); err != nil {
+			return err
+		}
+	default:
+		if err := x.enc.EncodeToken(xml.CharData(fmt.Sprintf("%v", t))); err != nil {
+			return err
+		}
+	}
+
+	return x.enc.EncodeToken(start.End())
+}
+
+// encodeXML - Writes the JSON structure of v as XML, objects become elements named after
+// their keys and list elements are wrapped in <item>
+func encodeXML(w io.Writer, v interface{}, opts Options) error {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.UseNumber()
+	enc := xml.NewEncoder(w)
+	if opts.Pretty {
+		enc.Indent("", "  ")
+	}
+
+	x := &xmlWriter{dec: dec, enc: enc}
+	if err := x.value(element(RootElement)); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,60 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header - The request and response header that carries the request ID
+const Header = "X-Request-ID"
+
+// maxLength - Longer incoming IDs are replaced
+const maxLength = 128
+
+type contextKey struct{}
+
+// New - Returns a random request ID
+func New() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// valid - Reports whether an incoming ID is short and only has characters that are safe to log
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// FromContext - Returns the request ID stored by Middleware, or ""
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Get - Returns the ID of a request, or ""
+func Get(r *http.Request) string {
+	return FromContext(r.Context())
+}
+
+// Middleware - Gives every request an ID, reusing the client's X-Request-ID if it is valid,
+// and echoes it in the response
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = New()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
@@ -0,0 +1,58 @@
+/**
+ * Sends edits of the meme sheet to the API server's /hooks/sheets webhook,
+ * so the server refreshes the changed rows instead of waiting for a manual update.
+ *
+ * Setup (Extensions > Apps Script in the sheet):
+ *   1. Paste this file into the script editor.
+ *   2. Project Settings > Script Properties: set HOOK_URL (e.g. https://example.com/hooks/sheets)
+ *      and HOOK_SECRET (the same value as the server's --hookSecret / SHEETS_HOOK_SECRET).
+ *   3. Triggers > Add Trigger: function onSheetEdit, event source "From spreadsheet", event type "On edit".
+ *      An installable trigger is needed, simple onEdit triggers can not make requests.
+ */
+
+function onSheetEdit(e) {
+  var props = PropertiesService.getScriptProperties();
+  var url = props.getProperty('HOOK_URL');
+  var secret = props.getProperty('HOOK_SECRET');
+  if (!url || !secret) {
+    return;
+  }
+
+  var range = e.range;
+  var values = range.getValues();
+  var edits = [];
+  for (var i = 0; i < values.length; i++) {
+    for (var j = 0; j < values[i].length; j++) {
+      var edit = {
+        row: range.getRow() + i,
+        column: range.getColumn() + j,
+        newValue: String(values[i][j]).trim()
+      };
+      // the old value is only known for single cell edits
+      if (values.length === 1 && values[0].length === 1 && e.oldValue !== undefined) {
+        edit.oldValue = String(e.oldValue);
+      }
+      edits.push(edit);
+    }
+  }
+
+  var body = JSON.stringify({ sheet: range.getSheet().getName(), edits: edits });
+  var timestamp = String(Math.floor(Date.now() / 1000));
+  var signature = Utilities.computeHmacSha256Signature(timestamp + '.' + body, secret)
+    .map(function (b) { return ('0' + (b & 0xff).toString(16)).slice(-2); })
+    .join('');
+
+  var res = UrlFetchApp.fetch(url, {
+    method: 'post',
+    contentType: 'application/json',
+    payload: body,
+    headers: {
+      'X-Hook-Timestamp': timestamp,
+      'X-Hook-Signature': signature
+    },
+    muteHttpExceptions: true
+  });
+  if (res.getResponseCode() !== 202) {
+    console.warn('Webhook answered ' + res.getResponseCode() + ': ' + res.getContentText());
+  }
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// HighlightStart - Inserted before a matching word in a snippet
+const HighlightStart = "<mark>"
+
+// HighlightEnd - Inserted after a matching word in a snippet
+const HighlightEnd = "</mark>"
+
+// matches - Reports whether any token of a word starts with one of the query terms
+func matches(word string, queryTerms []string) bool {
+	for _, token := range Tokenize(word) {
+		for _, term := range queryTerms {
+			if strings.HasPrefix(token, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Highlight - Returns an HTML escaped snippet of at most maxWords words of text around the
+// first word that matches the query, with matching words wrapped in <mark> tags.
+// If nothing matches the snippet is taken from the start of the text, maxWords 0 keeps all words
+func Highlight(text string, query string, maxWords int) string {
+	queryTerms := Tokenize(query)
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return ""
+	}
+
+	first := -1
+	marked := make([]bool, len(words))
+	for i, word := range words {
+		if matches(word, queryTerms) {
+			marked[i] = true
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+
+	// the whole text is kept without a limit, otherwise the snippet starts a third of
+	// the window before the first match
+	start := 0
+	if maxWords > 0 && first > maxWords/3 {
+		start = first - maxWords/3
+	}
+	end := start + maxWords
+	if maxWords <= 0 || end > len(words) {
+		end = len(words)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteString(" ")
+		}
+		if marked[i] {
+			b.WriteString(HighlightStart + html.EscapeString(words[i]) + HighlightEnd)
+		} else {
+			b.WriteString(html.EscapeString(words[i]))
+		}
+	}
+	if end < len(words) {
+		b.WriteString(" …")
+	}
+	return b.String()
+}
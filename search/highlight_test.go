@@ -0,0 +1,57 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		query    string
+		maxWords int
+		want     string
+	}{
+		{"marks every match", "Nyan Cat and another cat", "cat", 0, "Nyan <mark>Cat</mark> and another <mark>cat</mark>"},
+		{"prefix", "Keyboard Cat", "key", 0, "<mark>Keyboard</mark> Cat"},
+		{"punctuation around a match", "(cat)", "cat", 0, "<mark>(cat)</mark>"},
+		{"no match starts at the beginning", "one two three four", "cat", 2, "one two …"},
+		{"window around the first match", "a b c d e f g cat h i", "cat", 3, "… g <mark>cat</mark> h …"},
+		{"empty text", "   ", "cat", 5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, tt.query, tt.maxWords); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHighlightEscapes - Titles and descriptions come from the sheet and YouTube, so the
+// only markup in a snippet is the <mark> tags Highlight adds
+func TestHighlightEscapes(t *testing.T) {
+	tests := []struct {
+		text  string
+		query string
+		want  string
+	}{
+		{`<script>alert("cat")</script>`, "cat", `<mark>&lt;script&gt;alert(&#34;cat&#34;)&lt;/script&gt;</mark>`},
+		{`<img src=x onerror=alert(1)> cat`, "zzz", `&lt;img src=x onerror=alert(1)&gt; cat`},
+		{`</mark><b>cat</b>`, "cat", `<mark>&lt;/mark&gt;&lt;b&gt;cat&lt;/b&gt;</mark>`},
+		{`Tom & Jerry 'cat'`, "cat", `Tom &amp; Jerry <mark>&#39;cat&#39;</mark>`},
+		// the query is not echoed, so it can not inject anything either
+		{`cat`, `<b>cat</b>`, `<mark>cat</mark>`},
+	}
+	for _, tt := range tests {
+		got := Highlight(tt.text, tt.query, 0)
+		if got != tt.want {
+			t.Errorf("Highlight(%q, %q) = %q, want %q", tt.text, tt.query, got, tt.want)
+		}
+		rest := strings.NewReplacer(HighlightStart, "", HighlightEnd, "").Replace(got)
+		if strings.ContainsAny(rest, `<>"`) {
+			t.Errorf("Highlight(%q, %q) = %q lets markup through", tt.text, tt.query, got)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// prefixBoost - How much a prefix match counts compared to an exact term match
+const prefixBoost = 0.5
+
+// Field - A piece of text in a document, Boost scales how much matches in it count
+type Field struct {
+	Name  string
+	Text  string
+	Boost float64
+}
+
+// Document - Something that can be found by searching, made up of fields
+type Document struct {
+	Fields []Field
+}
+
+// posting - A document a term appears in and the boosted number of times it appears
+type posting struct {
+	doc    int
+	weight float64
+}
+
+// Index - An in-memory inverted index over a fixed list of documents
+type Index struct {
+	docCount int
+	// terms - every indexed term in sorted order, for prefix matching
+	terms    []string
+	postings map[string][]posting
+}
+
+// Hit - A document that matched a query
+type Hit struct {
+	// Doc - the index of the document in the list the Index was built from
+	Doc   int
+	Score float64
+}
+
+// Tokenize - Splits text into lower case words made of letters and digits
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// NewIndex - Builds an index over the documents
+func NewIndex(docs []Document) *Index {
+	ix := &Index{
+		docCount: len(docs),
+		postings: make(map[string][]posting),
+	}
+
+	for d, doc := range docs {
+		weights := make(map[string]float64)
+		for _, field := range doc.Fields {
+			boost := field.Boost
+			if boost == 0 {
+				boost = 1
+			}
+			for _, term := range Tokenize(field.Text) {
+				weights[term] += boost
+			}
+		}
+		for term, weight := range weights {
+			ix.postings[term] = append(ix.postings[term], posting{doc: d, weight: weight})
+		}
+	}
+
+	ix.terms = make([]string, 0, len(ix.postings))
+	for term := range ix.postings {
+		ix.terms = append(ix.terms, term)
+	}
+	sort.Strings(ix.terms)
+
+	return ix
+}
+
+// Len - Returns the number of documents in the index
+func (ix *Index) Len() int {
+	return ix.docCount
+}
+
+// idf - Inverse document frequency, rarer terms count for more
+func (ix *Index) idf(term string) float64 {
+	return math.Log(1 + float64(ix.docCount)/float64(len(ix.postings[term])))
+}
+
+// expand - Returns the indexed terms that match a query term exactly or by prefix
+func (ix *Index) expand(queryTerm string) []string {
+	start := sort.SearchStrings(ix.terms, queryTerm)
+	var matches []string
+	for i := start; i < len(ix.terms) && strings.HasPrefix(ix.terms[i], queryTerm); i++ {
+		matches = append(matches, ix.terms[i])
+	}
+	return matches
+}
+
+// Search - Returns every document that matches all terms of the query (exactly or as a
+// prefix), best matches first
+func (ix *Index) Search(query string) []Hit {
+	queryTerms := Tokenize(query)
+	if ix == nil || len(queryTerms) == 0 {
+		return nil
+	}
+
+	var scores map[int]float64
+	for _, queryTerm := range queryTerms {
+		termScores := make(map[int]float64)
+		for _, term := range ix.expand(queryTerm) {
+			boost := 1.0
+			if term != queryTerm {
+				boost = prefixBoost
+			}
+			idf := ix.idf(term)
+			for _, p := range ix.postings[term] {
+				termScores[p.doc] += boost * idf * p.weight
+			}
+		}
+
+		// every query term has to match
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for doc, score := range scores {
+			if termScore, ok := termScores[doc]; ok {
+				scores[doc] = score + termScore
+			} else {
+				delete(scores, doc)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for doc, score := range scores {
+		hits = append(hits, Hit{Doc: doc, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Doc < hits[j].Doc
+	})
+	return hits
+}
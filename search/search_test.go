@@ -0,0 +1,112 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"  Nyan-Cat 10 hours ", []string{"nyan", "cat", "10", "hours"}},
+		{"Ça va? ÜBER", []string{"ça", "va", "über"}},
+		{"it's <b>bold</b>", []string{"it", "s", "b", "bold", "b"}},
+		{"...", []string{}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// testIndex - Documents with a title and a description, the title counts twice
+func testIndex() *Index {
+	docs := []Document{
+		{Fields: []Field{{Name: "title", Text: "Nyan Cat", Boost: 2}, {Name: "description", Text: "the original"}}},
+		{Fields: []Field{{Name: "title", Text: "Keyboard Cat", Boost: 2}, {Name: "description", Text: "play him off"}}},
+		{Fields: []Field{{Name: "title", Text: "Dramatic Chipmunk", Boost: 2}, {Name: "description", Text: "a cat appears"}}},
+		{Fields: []Field{{Name: "title", Text: "Catalog of memes", Boost: 2}}},
+		{Fields: []Field{{Name: "title", Text: "Rickroll", Boost: 2}, {Name: "description", Text: "never gonna"}}},
+	}
+	return NewIndex(docs)
+}
+
+func docs(hits []Hit) []int {
+	ids := make([]int, len(hits))
+	for i, h := range hits {
+		ids[i] = h.Doc
+	}
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	ix := testIndex()
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		// the boosted title matches first, the rare catalog as a prefix in a title
+		// outweighs the common cat in a description
+		{"exact and prefix", "cat", []int{0, 1, 3, 2}},
+		{"prefix only", "chip", []int{2}},
+		{"case and punctuation", "NYAN!", []int{0}},
+		// every term has to match
+		{"and", "cat keyboard", []int{1}},
+		{"and without a common document", "nyan rickroll", []int{}},
+		{"prefixes of both terms", "dra ch", []int{2}},
+		{"no match", "doge", []int{}},
+		{"empty", "  ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ix.Search(tt.query)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("got %v, want nil", docs(got))
+				}
+				return
+			}
+			if !reflect.DeepEqual(docs(got), tt.want) {
+				t.Errorf("got %v, want %v", docs(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchIDF(t *testing.T) {
+	// "meme" is in every document, "rare" in one, so a document with both ranks
+	// above documents that only have the common term, however often they have it
+	ix := NewIndex([]Document{
+		{Fields: []Field{{Text: "meme meme meme"}}},
+		{Fields: []Field{{Text: "meme rare"}}},
+		{Fields: []Field{{Text: "meme meme"}}},
+	})
+	if got := ix.idf("rare"); got <= ix.idf("meme") {
+		t.Errorf("idf(rare) = %v is not above idf(meme) = %v", got, ix.idf("meme"))
+	}
+
+	hits := ix.Search("meme")
+	// same idf for every document, the term count decides
+	if want := []int{0, 2, 1}; !reflect.DeepEqual(docs(hits), want) {
+		t.Errorf("meme: got %v, want %v", docs(hits), want)
+	}
+	if hits[0].Score != 1.5*hits[1].Score {
+		t.Errorf("3 matches scored %v, 2 matches %v", hits[0].Score, hits[1].Score)
+	}
+
+	if got := docs(ix.Search("rare")); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("rare: got %v", got)
+	}
+}
+
+func TestSearchNilIndex(t *testing.T) {
+	var ix *Index
+	if hits := ix.Search("cat"); hits != nil {
+		t.Errorf("nil index found %v", hits)
+	}
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/lemonase/youtube-meme-api/api"
+	"github.com/lemonase/youtube-meme-api/auth"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/handlers"
+	"github.com/lemonase/youtube-meme-api/lint"
+	"github.com/lemonase/youtube-meme-api/metrics"
+	"github.com/lemonase/youtube-meme-api/random"
+	"github.com/lemonase/youtube-meme-api/tenant"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Version - The build version, set with -ldflags "-X github.com/lemonase/youtube-meme-api/server.Version=..."
+var Version = "dev"
+
+// Common parameters
+
+var formatParams = []api.Param{
+	{Name: "format", In: "query", Description: "Response format, overrides the Accept header", Enum: []string{"json", "ndjson", "csv", "xml"}},
+	{Name: "pretty", In: "query", Type: "boolean", Description: "Indent JSON and XML responses (default true)"},
+}
+
+var filterParams = []api.Param{
+	{Name: "source", In: "query", Description: "Only videos from this playlist ID (or channel uploads playlist ID)"},
+	{Name: "channel", In: "query", Description: "Only videos uploaded by this channel ID"},
+	{Name: "tag", In: "query", Description: "Only videos with this tag, repeat or separate with commas to require several"},
+	{Name: "excludeTag", In: "query", Description: "No videos with this tag, repeat or separate with commas to exclude several"},
+}
+
+var seedParam = api.Param{Name: "seed", In: "query", Type: "integer", Description: "Seed for a reproducible pick, echoed back in the X-Random-Seed header"}
+
+var strategyParam = api.Param{Name: "strategy", In: "query", Description: "How the video is picked", Enum: random.StrategyNames()}
+
+var sessionParam = api.Param{Name: "session", In: "query", Description: "Shuffle session token, defaults to the shuffle_session cookie"}
+
+var idParam = api.Param{Name: "id", In: "path", Description: "YouTube ID"}
+
+var limitParam = api.Param{Name: "limit", In: "query", Type: "integer", Description: "Number of items (default 50)"}
+
+// params - Concatenates parameter lists
+func params(lists ...[]api.Param) []api.Param {
+	var all []api.Param
+	for _, l := range lists {
+		all = append(all, l...)
+	}
+	return all
+}
+
+// budgets - The rate limit budget (see ratelimit.Budgets) of each route group,
+// groups without a budget are not limited
+var budgets = map[string]string{
+	"Pages":   "random",
+	"Random":  "random",
+	"Shuffle": "random",
+	"All":     "list",
+	"Lookup":  "list",
+	"Search":  "list",
+	"Tags":    "list",
+	"Export":  "list",
+	"Feeds":   "list",
+	"Admin":   "admin",
+	"Tenants": "admin",
+	"Hooks":   "admin",
+}
+
+// catalogGroups - Route groups that serve the catalog and answer 503 while it is loading
+var catalogGroups = map[string]bool{
+	"Pages":   true,
+	"Random":  true,
+	"Shuffle": true,
+	"All":     true,
+	"Lookup":  true,
+	"Search":  true,
+	"Tags":    true,
+	"Export":  true,
+	"Feeds":   true,
+}
+
+// tenantGroups - Route groups that are also served for every tenant under /t/{tenant}
+var tenantGroups = catalogGroups
+
+// lintParams - The parameters of the lint report, which can also be an HTML page
+var lintParams = []api.Param{
+	{Name: "tenant", In: "query", Description: "Check a tenant's sheet instead of the main one"},
+	{Name: "format", In: "query", Description: "Response format, overrides the Accept header", Enum: []string{"json", "ndjson", "csv", "xml", "html"}},
+	{Name: "pretty", In: "query", Type: "boolean", Description: "Indent JSON and XML responses (default true)"},
+}
+
+// tenantParam - The path parameter of the tenant admin routes
+var tenantParam = api.Param{Name: "id", In: "path", Description: "Tenant ID"}
+
+// Routes - Every endpoint of the server
+var Routes = []api.Route{
+	{Method: http.MethodGet, Path: "/", Group: "Pages", Summary: "Home page with a video from your shuffle session (or a seeded pick)",
+		Params: params([]api.Param{seedParam, strategyParam}, filterParams), ContentType: "text/html", Handler: handlers.Home},
+
+	// random
+	{Method: http.MethodGet, Path: "/api/v1/random/video", Group: "Random", Summary: "Gets a random video",
+		Params: params([]api.Param{seedParam, strategyParam}, filterParams, formatParams), Response: &youtube.VideoListResponse{}, Handler: handlers.RandomVideo},
+	{Method: http.MethodGet, Path: "/api/v1/random/playlist", Group: "Random", Summary: "Gets a random playlist",
+		Params: params([]api.Param{seedParam}, formatParams), Response: &youtube.PlaylistListResponse{}, Handler: handlers.RandomPlaylist},
+	{Method: http.MethodGet, Path: "/api/v1/random/playlist/item", Group: "Random", Summary: "Gets a random playlist item (playlist video)",
+		Params: params([]api.Param{seedParam, strategyParam}, filterParams, formatParams), Response: &youtube.PlaylistItem{}, Handler: handlers.RandomPlaylistItem},
+	{Method: http.MethodGet, Path: "/api/v1/random/channel", Group: "Random", Summary: "Gets a random channel",
+		Params: params([]api.Param{seedParam}, formatParams), Response: &youtube.ChannelListResponse{}, Handler: handlers.RandomChannel},
+
+	// shuffle
+	{Method: http.MethodGet, Path: "/api/v1/shuffle/playlist/item", Group: "Shuffle", Summary: "Gets the next playlist item in your shuffle session",
+		Params: params([]api.Param{sessionParam}, filterParams, formatParams), Response: &youtube.PlaylistItem{}, Handler: handlers.ShufflePlaylistItem},
+
+	// all
+	{Method: http.MethodGet, Path: "/api/v1/all/video", Group: "All", Summary: "Gets all videos, a filter keeps only the matching videos of every response",
+		Params: params(filterParams, formatParams), Response: []*youtube.VideoListResponse{}, Handler: handlers.AllVideos},
+	{Method: http.MethodGet, Path: "/api/v1/all/playlist", Group: "All", Summary: "Gets all playlists",
+		Params: formatParams, Response: []*youtube.PlaylistListResponse{}, Handler: handlers.AllPlaylists},
+	{Method: http.MethodGet, Path: "/api/v1/all/playlist/item", Group: "All", Summary: "Gets all playlist items/videos, a filter keeps only the matching items of every response",
+		Params: params(filterParams, formatParams), Response: []*youtube.PlaylistItemListResponse{}, Handler: handlers.AllPlaylistsWithItems},
+	{Method: http.MethodGet, Path: "/api/v1/all/channel", Group: "All", Summary: "Gets all channels",
+		Params: formatParams, Response: []*youtube.ChannelListResponse{}, Handler: handlers.AllChannels},
+
+	// lookup
+	{Method: http.MethodGet, Path: "/api/v1/video/{id}", Pattern: "/api/v1/video/", Group: "Lookup", Summary: "Gets a single video from the catalog",
+		Params: params([]api.Param{idParam}, formatParams), Response: &catalog.Entry{}, Handler: handlers.VideoByID},
+	{Method: http.MethodGet, Path: "/api/v1/playlist/{id}", Pattern: "/api/v1/playlist/", Group: "Lookup", Summary: "Gets a single playlist",
+		Params: params([]api.Param{idParam}, formatParams), Response: &youtube.PlaylistListResponse{}, Handler: handlers.PlaylistByID},
+	{Method: http.MethodGet, Path: "/api/v1/playlist/{id}/items", Pattern: "/api/v1/playlist/", Group: "Lookup", Summary: "Gets the videos of a playlist",
+		Params: params([]api.Param{idParam}, formatParams), Response: catalog.Entries{}, Handler: handlers.PlaylistByID},
+	{Method: http.MethodGet, Path: "/api/v1/channel/{id}", Pattern: "/api/v1/channel/", Group: "Lookup", Summary: "Gets a single channel",
+		Params: params([]api.Param{idParam}, formatParams), Response: &youtube.ChannelListResponse{}, Handler: handlers.ChannelByID},
+	{Method: http.MethodPost, Path: "/api/v1/videos:batchGet", Group: "Lookup", Summary: "Gets up to 100 videos at once",
+		Params: formatParams, Request: &handlers.BatchGetRequest{}, Response: &handlers.BatchGetResponse{}, Handler: handlers.BatchGetVideos},
+
+	// tags
+	{Method: http.MethodGet, Path: "/api/v1/tags", Group: "Tags", Summary: "Gets every tag of the sheet's tags column with the number of videos that have it",
+		Params: formatParams, Response: &handlers.TagsResponse{}, Handler: handlers.Tags},
+
+	// search
+	{Method: http.MethodGet, Path: "/api/v1/search", Group: "Search", Summary: "Searches video and playlist titles, descriptions, channel names and tags",
+		Params: params([]api.Param{
+			{Name: "q", In: "query", Required: true, Description: "Search query, every word has to match exactly or as a prefix"},
+			{Name: "type", In: "query", Description: "Only return one kind of result", Enum: []string{"video", "playlist"}},
+			{Name: "page", In: "query", Type: "integer", Description: "Page number (default 1)"},
+			{Name: "pageSize", In: "query", Type: "integer", Description: "Results per page (default 20, at most 100)"},
+			{Name: "random", In: "query", Type: "boolean", Description: "Return a single random result matching the query"},
+			seedParam,
+		}, formatParams), Response: &handlers.SearchResponse{}, Handler: handlers.Search},
+
+	// export
+	{Method: http.MethodGet, Path: "/api/v1/export/playlist.m3u", Pattern: "/api/v1/export/", Group: "Export", Summary: "Gets the catalog as an M3U playlist",
+		Params: params(filterParams, []api.Param{seedParam, {Name: "shuffle", In: "query", Type: "boolean", Description: "Shuffle with a new seed"}}), ContentType: "audio/x-mpegurl", Handler: handlers.ExportPlaylist},
+	{Method: http.MethodGet, Path: "/api/v1/export/playlist.xspf", Pattern: "/api/v1/export/", Group: "Export", Summary: "Gets the catalog as an XSPF playlist",
+		Params: params(filterParams, []api.Param{seedParam, {Name: "shuffle", In: "query", Type: "boolean", Description: "Shuffle with a new seed"}}), ContentType: "application/xspf+xml", Handler: handlers.ExportPlaylist},
+
+	// feeds
+	{Method: http.MethodGet, Path: "/feeds/new.atom", Pattern: "/feeds/", Group: "Feeds", Summary: "Newest memes as an Atom feed",
+		Params: params(filterParams, []api.Param{limitParam}), ContentType: "application/atom+xml", Handler: handlers.Feed},
+	{Method: http.MethodGet, Path: "/feeds/new.rss", Pattern: "/feeds/", Group: "Feeds", Summary: "Newest memes as an RSS feed",
+		Params: params(filterParams, []api.Param{limitParam}), ContentType: "application/rss+xml", Handler: handlers.Feed},
+	{Method: http.MethodGet, Path: "/feeds/new.json", Pattern: "/feeds/", Group: "Feeds", Summary: "Newest memes as a JSON Feed",
+		Params: params(filterParams, []api.Param{limitParam}), ContentType: "application/feed+json", Handler: handlers.Feed},
+	{Method: http.MethodGet, Path: "/feeds/channel/{id}/new.atom", Pattern: "/feeds/", Group: "Feeds", Summary: "Newest memes from one channel as an Atom feed",
+		Params: []api.Param{idParam, limitParam}, ContentType: "application/atom+xml", Handler: handlers.Feed},
+	{Method: http.MethodGet, Path: "/feeds/channel/{id}/new.rss", Pattern: "/feeds/", Group: "Feeds", Summary: "Newest memes from one channel as an RSS feed",
+		Params: []api.Param{idParam, limitParam}, ContentType: "application/rss+xml", Handler: handlers.Feed},
+	{Method: http.MethodGet, Path: "/feeds/channel/{id}/new.json", Pattern: "/feeds/", Group: "Feeds", Summary: "Newest memes from one channel as a JSON Feed",
+		Params: []api.Param{idParam, limitParam}, ContentType: "application/feed+json", Handler: handlers.Feed},
+
+	// health
+	{Method: http.MethodGet, Path: "/healthz", Group: "Health", Summary: "Answers 200 while the server is running",
+		Response: &handlers.Health{}, Handler: handlers.Healthz},
+	{Method: http.MethodGet, Path: "/readyz", Group: "Health", Summary: "Answers 200 once the catalog is loaded and not empty, 503 before that",
+		Response: &handlers.Health{}, Handler: handlers.Readyz},
+	{Method: http.MethodGet, Path: "/api/v1/status", Group: "Health", Summary: "Gets the catalog sizes, last refreshes and errors, cache file ages and build version",
+		Params: formatParams, Response: &handlers.ServerStatus{}, Handler: handlers.Status(Version)},
+	{Method: http.MethodGet, Path: "/metrics", Group: "Health", Summary: "Request, random pick, catalog, refresh and Google API metrics in the Prometheus text format",
+		ContentType: "text/plain", Handler: metrics.Handler},
+
+	// admin
+	{Method: http.MethodPost, Path: "/api/admin/refresh/all", Group: "Admin", Summary: "Refetches everything from the sheet and YouTube",
+		Params: formatParams, Response: &handlers.RefreshResponse{}, Scope: string(auth.ScopeRefresh), Handler: handlers.UpdateAllValuesFromSheet},
+	{Method: http.MethodPost, Path: "/api/admin/refresh/video", Group: "Admin", Summary: "Refetches videos if the video column changed",
+		Params: formatParams, Response: &handlers.RefreshResponse{}, Scope: string(auth.ScopeRefresh), Handler: handlers.UpdateAllVideosFromSheet},
+	{Method: http.MethodPost, Path: "/api/admin/refresh/playlist", Group: "Admin", Summary: "Refetches playlists if the playlist column changed",
+		Params: formatParams, Response: &handlers.RefreshResponse{}, Scope: string(auth.ScopeRefresh), Handler: handlers.UpdateAllPlaylistsFromSheet},
+	{Method: http.MethodPost, Path: "/api/admin/refresh/channel", Group: "Admin", Summary: "Refetches channels if the channel column changed",
+		Params: formatParams, Response: &handlers.RefreshResponse{}, Scope: string(auth.ScopeRefresh), Handler: handlers.UpdateAllChannelsFromSheet},
+	{Method: http.MethodGet, Path: "/api/admin/stats", Group: "Admin", Summary: "Gets server statistics",
+		Params: formatParams, Response: &handlers.AdminStats{}, Scope: string(auth.ScopeReadStats), Handler: handlers.Stats},
+	{Method: http.MethodGet, Path: "/api/admin/sheet/lint", Group: "Admin", Summary: "Checks every video, playlist and channel cell of the sheet for unparseable, non-YouTube, misplaced, duplicate and unresolvable links",
+		Params: lintParams, Response: &lint.Report{}, Scope: string(auth.ScopeModerate), Handler: handlers.SheetLint},
+
+	// tenants
+	{Method: http.MethodGet, Path: "/api/admin/tenants", Group: "Tenants", Summary: "Lists the registered tenants with their catalog sizes, refreshes and quota used",
+		Params: formatParams, Response: &handlers.TenantList{}, Scope: string(auth.ScopeModerate), Handler: handlers.Tenants},
+	{Method: http.MethodPost, Path: "/api/admin/tenants", Group: "Tenants", Summary: "Registers a tenant with its own sheet, served under /t/{id}/",
+		Params: formatParams, Request: &tenant.Config{}, Response: &handlers.TenantInfo{}, Scope: string(auth.ScopeModerate), Handler: handlers.Tenants},
+	{Method: http.MethodGet, Path: "/api/admin/tenants/{id}", Pattern: "/api/admin/tenants/", Group: "Tenants", Summary: "Gets a tenant",
+		Params: params([]api.Param{tenantParam}, formatParams), Response: &handlers.TenantInfo{}, Scope: string(auth.ScopeModerate), Handler: handlers.TenantByID},
+	{Method: http.MethodDelete, Path: "/api/admin/tenants/{id}", Pattern: "/api/admin/tenants/", Group: "Tenants", Summary: "Unregisters a tenant, its stored responses are kept",
+		Params: []api.Param{tenantParam}, Scope: string(auth.ScopeModerate), Handler: handlers.TenantByID},
+	{Method: http.MethodPost, Path: "/api/admin/tenants/{id}/refresh", Pattern: "/api/admin/tenants/", Group: "Tenants", Summary: "Refetches a tenant's sheet and YouTube data, if it has quota left today",
+		Params: params([]api.Param{tenantParam}, formatParams), Response: &handlers.RefreshResponse{}, Scope: string(auth.ScopeModerate), Handler: handlers.TenantByID},
+
+	// hooks
+	{Method: http.MethodPost, Path: "/hooks/sheets", Group: "Hooks", Summary: "Receives signed cell edits from the sheet's Apps Script trigger (see scripts/sheets-webhook.gs)",
+		Request: &handlers.SheetEdits{}, Response: &handlers.HookResponse{}, Handler: handlers.SheetsHook},
+}
+
+// docRoutes - Routes serving the documentation of Routes, added in init since they refer to it
+var docRoutes = []api.Route{
+	{Method: http.MethodGet, Path: "/api/openapi.json", Group: "Docs", Summary: "This API as an OpenAPI 3 document", Response: map[string]interface{}{}},
+	{Method: http.MethodGet, Path: "/api/docs", Group: "Docs", Summary: "Interactive API documentation", ContentType: "text/html"},
+}
+
+func init() {
+	docRoutes[0].Handler = handlers.OpenAPISpec(api.OpenAPI("YouTube Meme API", Version, "Random meme videos, playlists and channels from a Google Sheet", allRoutes()))
+	docRoutes[1].Handler = handlers.Docs(allRoutes())
+}
+
+// tenantRoutes - Returns the routes of the tenantGroups, served under /t/{tenant}
+func tenantRoutes() []api.Route {
+	var routes []api.Route
+	for _, route := range Routes {
+		if tenantGroups[route.Group] {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// allRoutes - Returns Routes followed by the documentation routes
+func allRoutes() []api.Route {
+	return append(append([]api.Route{}, Routes...), docRoutes...)
+}
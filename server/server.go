@@ -1,47 +1,195 @@
 package server
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/lemonase/youtube-meme-api/api"
+	"github.com/lemonase/youtube-meme-api/apierror"
+	"github.com/lemonase/youtube-meme-api/auth"
+	"github.com/lemonase/youtube-meme-api/client"
+	"github.com/lemonase/youtube-meme-api/compress"
 	"github.com/lemonase/youtube-meme-api/handlers"
+	"github.com/lemonase/youtube-meme-api/logging"
+	"github.com/lemonase/youtube-meme-api/metrics"
+	"github.com/lemonase/youtube-meme-api/ratelimit"
+	"github.com/lemonase/youtube-meme-api/requestid"
+	"github.com/lemonase/youtube-meme-api/tenant"
 )
 
-// InitServer - Sets all routes and initializes the server
+// allowMethods - Only lets requests with one of the methods through (GET also allows HEAD)
+func allowMethods(methods []string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, m := range methods {
+			if r.Method == m || (m == http.MethodGet && r.Method == http.MethodHead) {
+				h(w, r)
+				return
+			}
+		}
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		apierror.Write(w, r, http.StatusMethodNotAllowed, "Method not allowed, use "+strings.Join(methods, " or "))
+	}
+}
+
+// register - Adds every route to the mux. Routes that share a pattern share a handler,
+// the first route with a pattern decides which handler serves it, whether it waits for the catalog,
+// which admin scope it requires and which rate limit budget it counts against. Requests are counted per
+// pattern (after prefix) in the metrics
+func register(mux *http.ServeMux, prefix string, routes []api.Route) {
+	var patterns []string
+	handlerOf := make(map[string]http.HandlerFunc)
+	methodsOf := make(map[string][]string)
+
+	for _, route := range routes {
+		pattern := route.MuxPattern()
+		if _, ok := handlerOf[pattern]; !ok {
+			patterns = append(patterns, pattern)
+			h := route.Handler
+			if catalogGroups[route.Group] {
+				h = handlers.WaitForCatalog(h)
+			}
+			if route.Scope != "" {
+				h = auth.Require(auth.Scope(route.Scope), h)
+			}
+			handlerOf[pattern] = ratelimit.Middleware(budgets[route.Group], h)
+		}
+		known := false
+		for _, m := range methodsOf[pattern] {
+			known = known || m == route.Method
+		}
+		if !known {
+			methodsOf[pattern] = append(methodsOf[pattern], route.Method)
+		}
+	}
+
+	for _, pattern := range patterns {
+		mux.HandleFunc(pattern, metrics.Middleware(prefix+pattern, allowMethods(methodsOf[pattern], handlerOf[pattern])))
+	}
+}
+
+// DrainTimeout - How long a shutdown waits for running requests and refreshes
+var DrainTimeout = 10 * time.Second
+
+// RefreshInterval - How often everything is refetched, 0 turns the schedule off
+var RefreshInterval time.Duration
+
+// scheduleRefreshes - Runs handlers.ScheduledRefresh every RefreshInterval until ctx is done
+func scheduleRefreshes(ctx context.Context) {
+	if RefreshInterval <= 0 {
+		return
+	}
+	slog.Info("refreshing on a schedule", "interval", RefreshInterval.String())
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			handlers.ScheduledRefresh()
+		}
+	}
+}
+
+// TenantCheckInterval - How often tenants are checked for due refreshes
+var TenantCheckInterval = time.Minute
+
+// scheduleTenantRefreshes - Refreshes the tenants whose refresh interval passed until ctx is done
+func scheduleTenantRefreshes(ctx context.Context) {
+	ticker := time.NewTicker(TenantCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			handlers.RefreshDueTenants()
+		}
+	}
+}
+
+// InitServer - Sets all routes and serves until SIGINT or SIGTERM, then stops accepting
+// connections, cancels refreshes and waits up to DrainTimeout for running requests.
+// Everything is refetched every RefreshInterval while serving
 func InitServer(port string) {
+	if err := handlers.ParseTemplates(); err != nil {
+		slog.Error("could not parse the page templates", "err", err)
+		os.Exit(1)
+	}
 
 	mux := http.NewServeMux()
 
-	// serves webpage
-	mux.HandleFunc("/", handlers.Home)
+	// api and webpage routes, see routes.go
+	register(mux, "", allRoutes())
+
+	// the catalog routes of every tenant
+	tenantMux := http.NewServeMux()
+	register(tenantMux, "/t/{tenant}", tenantRoutes())
+	tenantMux.HandleFunc("/api/", metrics.Middleware("/t/{tenant}/api/", handlers.APIHelper(tenantRoutes())))
+	mux.HandleFunc(tenant.PathPrefix, handlers.ServeTenant(tenantMux))
+
+	// lists the endpoints for unknown api paths
+	mux.HandleFunc("/api/", metrics.Middleware("/api/", handlers.APIHelper(allRoutes())))
 
-	// api
-	mux.HandleFunc("/api/", handlers.APIHelper)
+	server := http.Server{Addr: port, Handler: requestid.Middleware(logging.AccessLog(apierror.Recover(compress.Middleware(mux))))}
 
-	// random
-	mux.HandleFunc("/api/v1/random/video", handlers.RandomVideo)
-	mux.HandleFunc("/api/v1/random/playlist", handlers.RandomPlaylist)
-	mux.HandleFunc("/api/v1/random/playlist/item", handlers.RandomPlaylistItem)
-	mux.HandleFunc("/api/v1/random/channel", handlers.RandomChannel)
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// all
-	mux.HandleFunc("/api/v1/all/video", handlers.AllVideos)
-	mux.HandleFunc("/api/v1/all/playlist", handlers.AllPlaylists)
-	mux.HandleFunc("/api/v1/all/playlist/item", handlers.AllPlaylistsWithItems)
-	mux.HandleFunc("/api/v1/all/channel", handlers.AllChannels)
+	go scheduleRefreshes(signals)
+	go scheduleTenantRefreshes(signals)
 
-	// updates
-	mux.HandleFunc("/api/v1/update/all", handlers.UpdateAllValuesFromSheet)
-	mux.HandleFunc("/api/v1/update/video", handlers.UpdateAllVideosFromSheet)
-	mux.HandleFunc("/api/v1/update/playlist", handlers.UpdateAllPlaylistsFromSheet)
-	mux.HandleFunc("/api/v1/update/channel", handlers.UpdateAllChannelsFromSheet)
+	failed := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", "addr", port)
+		failed <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-failed:
+		slog.Error("server stopped", "err", err)
+		os.Exit(1)
+	case <-signals.Done():
+	}
+	// a second signal exits right away
+	stop()
+
+	slog.Info("shutting down", "drainTimeout", DrainTimeout.String())
+	ctx, cancel := context.WithTimeout(context.Background(), DrainTimeout)
+	defer cancel()
+
+	client.Cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("requests were still running at the drain timeout", "err", err)
+	}
+	if err := handlers.Shutdown(ctx); err != nil {
+		slog.Warn("a refresh was still running at the drain timeout", "err", err)
+	}
+	slog.Info("server stopped")
+}
+
+// LoadCachedResources - Serves the responses saved by the last run (of the main sheet and
+// every tenant) until FetchInitResources is done
+func LoadCachedResources() {
+	handlers.LoadCached()
+	handlers.LoadTenants()
+}
 
-	server := http.Server{Addr: port, Handler: mux}
-	log.Printf("Server listenting on *%s", port)
-	log.Fatal(server.ListenAndServe())
+// FetchMainResources - Calls sheets and youtube APIs for the main sheet's data only, for
+// --export, which writes the main catalog and never loads tenants
+func FetchMainResources() {
+	if err := handlers.InitialFetch(); err != nil {
+		slog.Error("could not fetch initial resources", "err", err)
+	}
 }
 
-// FetchInitResources - Calls sheets and youtube APIs for data
+// FetchInitResources - Calls sheets and youtube APIs for data, tenants are fetched after the main sheet
 func FetchInitResources() {
-	handlers.FetchAllYoutubeInfoFromSheet(false)
+	FetchMainResources()
+	handlers.InitialTenantFetch()
 }
@@ -0,0 +1,93 @@
+package status
+
+import (
+	"sync"
+	"time"
+)
+
+// Refresh - When a source was last loaded and the last error loading it
+type Refresh struct {
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// Error - The last error of any source
+type Error struct {
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// Sources - The refresh status of the sources of one catalog
+type Sources struct {
+	mu        sync.Mutex
+	refreshes map[string]*Refresh
+	lastError *Error
+}
+
+// NewSources - Returns an empty status
+func NewSources() *Sources {
+	return &Sources{refreshes: make(map[string]*Refresh)}
+}
+
+// Main - The sources of the main catalog, reported by Refreshes and LastError
+var Main = NewSources()
+
+// StartedAt - When the process started
+var StartedAt = time.Now()
+
+// Record - Records the outcome of loading a source ("sheet", "channel", "playlist", "playlistItem" or "video").
+// loaded is true if the source was (at least partly) loaded, err holds what could not be
+func (s *Sources) Record(source string, loaded bool, err error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.refreshes[source]
+	if r == nil {
+		r = &Refresh{}
+		s.refreshes[source] = r
+	}
+	if loaded {
+		r.LastSuccess = &now
+	}
+	if err != nil {
+		r.LastError, r.LastErrorAt = err.Error(), &now
+		s.lastError = &Error{Source: source, Message: err.Error(), At: now}
+	}
+}
+
+// Refreshes - Returns a copy of the refresh status of every source that was loaded at least once
+func (s *Sources) Refreshes() map[string]Refresh {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := make(map[string]Refresh, len(s.refreshes))
+	for source, r := range s.refreshes {
+		copied[source] = *r
+	}
+	return copied
+}
+
+// LastError - Returns the last error of any source, or nil if there was none
+func (s *Sources) LastError() *Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastError == nil {
+		return nil
+	}
+	e := *s.lastError
+	return &e
+}
+
+// Refreshes - Returns the refresh status of the main catalog's sources
+func Refreshes() map[string]Refresh {
+	return Main.Refreshes()
+}
+
+// LastError - Returns the last error of the main catalog's sources
+func LastError() *Error {
+	return Main.LastError()
+}
@@ -0,0 +1,132 @@
+package tenant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileName - File in the main data directory the registered tenants are kept in
+var FileName = "tenants.json"
+
+// ErrExists - A tenant with the ID is already registered
+var ErrExists = errors.New("tenant already exists")
+
+var (
+	mu      sync.RWMutex
+	tenants = make(map[string]*Tenant)
+	dataDir string
+)
+
+// Load - Reads the registered tenants from FileName in dir, the directory their responses are stored under
+func Load(dir string) ([]*Tenant, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	dataDir = dir
+	data, err := ioutil.ReadFile(filepath.Join(dir, FileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var configs []Config
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", FileName, err)
+	}
+	for _, c := range configs {
+		c = c.withDefaults()
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("tenant %s in %s: %v", c.ID, FileName, err)
+		}
+		tenants[c.ID] = newTenant(c, dir)
+	}
+	return sortedLocked(), nil
+}
+
+// save - Writes the registered tenants to a temporary file and moves it into place
+func save() error {
+	configs := make([]Config, 0, len(tenants))
+	for _, t := range sortedLocked() {
+		configs = append(configs, t.Config)
+	}
+	j, err := json.MarshalIndent(configs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return err
+	}
+	file := filepath.Join(dataDir, FileName)
+	if err := ioutil.WriteFile(file+".tmp", j, 0644); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
+
+// Add - Validates and registers a tenant, the main sheet's ranges are used for the ranges it leaves empty
+func Add(c Config) (*Tenant, error) {
+	c = c.withDefaults()
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = time.Now().UTC()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := tenants[c.ID]; ok {
+		return nil, ErrExists
+	}
+	t := newTenant(c, dataDir)
+	tenants[c.ID] = t
+	if err := save(); err != nil {
+		delete(tenants, c.ID)
+		return nil, fmt.Errorf("could not save tenants: %v", err)
+	}
+	return t, nil
+}
+
+// Remove - Unregisters a tenant, its stored responses are kept. Reports whether it was registered
+func Remove(id string) (bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	t, ok := tenants[id]
+	if !ok {
+		return false, nil
+	}
+	delete(tenants, id)
+	if err := save(); err != nil {
+		tenants[id] = t
+		return true, fmt.Errorf("could not save tenants: %v", err)
+	}
+	return true, nil
+}
+
+// Get - Returns a registered tenant, or nil
+func Get(id string) *Tenant {
+	mu.RLock()
+	defer mu.RUnlock()
+	return tenants[id]
+}
+
+// All - Returns every registered tenant by ID
+func All() []*Tenant {
+	mu.RLock()
+	defer mu.RUnlock()
+	return sortedLocked()
+}
+
+func sortedLocked() []*Tenant {
+	list := make([]*Tenant, 0, len(tenants))
+	for _, t := range tenants {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
@@ -0,0 +1,216 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/lemonase/youtube-meme-api/api-wrappers/sheets"
+	"github.com/lemonase/youtube-meme-api/api-wrappers/youtube"
+	"github.com/lemonase/youtube-meme-api/catalog"
+	"github.com/lemonase/youtube-meme-api/status"
+)
+
+// PathPrefix - Tenant catalogs are served under PathPrefix + ID
+const PathPrefix = "/t/"
+
+// MinRefreshInterval - The shortest refresh interval a tenant can have, refreshes cost API quota
+const MinRefreshInterval = 15 * time.Minute
+
+// validID - Tenant IDs are used in paths and directory names
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Ranges - The single column A1 ranges of a tenant's sheet, empty ranges default to the main sheet's
+type Ranges struct {
+	Videos          string `json:"videos,omitempty"`
+	VideoWeights    string `json:"videoWeights,omitempty"`
+	Playlists       string `json:"playlists,omitempty"`
+	PlaylistWeights string `json:"playlistWeights,omitempty"`
+	Channels        string `json:"channels,omitempty"`
+	ChannelWeights  string `json:"channelWeights,omitempty"`
+	Searches        string `json:"searches,omitempty"`
+	Tags            string `json:"tags,omitempty"`
+	Starts          string `json:"starts,omitempty"`
+	Ends            string `json:"ends,omitempty"`
+	Titles          string `json:"titles,omitempty"`
+	Notes           string `json:"notes,omitempty"`
+	Dates           string `json:"dates,omitempty"`
+	Credits         string `json:"credits,omitempty"`
+}
+
+// Config - What a tenant is registered with
+type Config struct {
+	ID      string `json:"id"`
+	Title   string `json:"title,omitempty"`
+	SheetID string `json:"sheetId"`
+	Ranges  Ranges `json:"ranges"`
+	// RefreshInterval - how often the tenant's catalog is refetched, like "24h", "" or "0s" for never
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+	// DailyQuota - the YouTube API quota units the tenant's refreshes may use per UTC day, 0 for no limit
+	DailyQuota int64     `json:"dailyQuota,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// withDefaults - Fills in the ranges the config leaves empty with the main sheet's
+func (c Config) withDefaults() Config {
+	for _, r := range []struct {
+		value *string
+		main  string
+	}{
+		{&c.Ranges.Videos, sheets.Main.VideoRange}, {&c.Ranges.VideoWeights, sheets.Main.VideoWeightRange},
+		{&c.Ranges.Playlists, sheets.Main.PlaylistRange}, {&c.Ranges.PlaylistWeights, sheets.Main.PlaylistWeightRange},
+		{&c.Ranges.Channels, sheets.Main.ChannelRange}, {&c.Ranges.ChannelWeights, sheets.Main.ChannelWeightRange},
+		{&c.Ranges.Searches, sheets.Main.SearchRange}, {&c.Ranges.Tags, sheets.Main.TagRange},
+		{&c.Ranges.Starts, sheets.Main.StartRange}, {&c.Ranges.Ends, sheets.Main.EndRange},
+		{&c.Ranges.Titles, sheets.Main.TitleRange}, {&c.Ranges.Notes, sheets.Main.NoteRange},
+		{&c.Ranges.Dates, sheets.Main.DateRange}, {&c.Ranges.Credits, sheets.Main.CreditRange},
+	} {
+		if *r.value == "" {
+			*r.value = r.main
+		}
+	}
+	return c
+}
+
+// Validate - Checks the ID, sheet, ranges, refresh interval and quota
+func (c Config) Validate() error {
+	if !validID.MatchString(c.ID) {
+		return fmt.Errorf("invalid tenant id %q, use up to 32 lowercase letters, digits and dashes", c.ID)
+	}
+	if c.SheetID == "" {
+		return fmt.Errorf("sheetId is required")
+	}
+	for _, r := range []string{c.Ranges.Videos, c.Ranges.VideoWeights, c.Ranges.Playlists, c.Ranges.PlaylistWeights,
+		c.Ranges.Channels, c.Ranges.ChannelWeights, c.Ranges.Searches, c.Ranges.Tags, c.Ranges.Starts, c.Ranges.Ends,
+		c.Ranges.Titles, c.Ranges.Notes, c.Ranges.Dates, c.Ranges.Credits} {
+		if _, err := sheets.ParseRange(r); err != nil {
+			return err
+		}
+	}
+	if _, err := c.interval(); err != nil {
+		return err
+	}
+	if c.DailyQuota < 0 {
+		return fmt.Errorf("dailyQuota can not be negative")
+	}
+	return nil
+}
+
+// interval - Parses RefreshInterval
+func (c Config) interval() (time.Duration, error) {
+	if c.RefreshInterval == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.RefreshInterval)
+	if err != nil {
+		return 0, fmt.Errorf("refreshInterval %q is not a duration like 24h", c.RefreshInterval)
+	}
+	if d != 0 && d < MinRefreshInterval {
+		return 0, fmt.Errorf("refreshInterval has to be 0 or at least %s", MinRefreshInterval)
+	}
+	return d, nil
+}
+
+// Tenant - A registered sheet with its own catalog, refresh status and quota
+type Tenant struct {
+	Config
+	// Sources - the refresh status of the tenant's sheet and responses
+	Sources *status.Sources
+	// Catalog - the tenant's sheet, responses and snapshot
+	Catalog *catalog.Catalog
+
+	mu          sync.Mutex
+	lastRefresh time.Time
+	quotaDay    string
+	quotaUsed   int64
+}
+
+// newTenant - Returns a tenant with an empty catalog whose responses are stored in a
+// directory of its own under dataDir
+func newTenant(c Config, dataDir string) *Tenant {
+	t := &Tenant{Config: c, Sources: status.NewSources()}
+	sheet := &sheets.Sheet{
+		SheetID:    c.SheetID,
+		VideoRange: c.Ranges.Videos, VideoWeightRange: c.Ranges.VideoWeights,
+		PlaylistRange: c.Ranges.Playlists, PlaylistWeightRange: c.Ranges.PlaylistWeights,
+		ChannelRange: c.Ranges.Channels, ChannelWeightRange: c.Ranges.ChannelWeights,
+		SearchRange: c.Ranges.Searches, TagRange: c.Ranges.Tags,
+		StartRange: c.Ranges.Starts, EndRange: c.Ranges.Ends,
+		TitleRange: c.Ranges.Titles, NoteRange: c.Ranges.Notes, DateRange: c.Ranges.Dates, CreditRange: c.Ranges.Credits,
+		Sources: t.Sources,
+	}
+	store := &youtube.Store{DataDirectory: filepath.Join(dataDir, "tenants", c.ID), Sheet: sheet, Sources: t.Sources}
+	t.Catalog = catalog.New(sheet, store)
+	return t
+}
+
+// Path - Returns the path the tenant is served under, without a trailing slash
+func (t *Tenant) Path() string {
+	return PathPrefix + t.ID
+}
+
+// Interval - Returns how often the tenant is refreshed, 0 for never
+func (t *Tenant) Interval() time.Duration {
+	d, _ := t.interval()
+	return d
+}
+
+// LastRefresh - Returns when the tenant's last refresh finished, zero if it never did
+func (t *Tenant) LastRefresh() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastRefresh
+}
+
+// Due - Reports whether the refresh interval has passed since the last refresh
+func (t *Tenant) Due(now time.Time) bool {
+	interval := t.Interval()
+	return interval > 0 && now.Sub(t.LastRefresh()) >= interval
+}
+
+// today - The UTC day quota is counted for
+func today() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+// QuotaUsed - Returns the quota units the tenant used today
+func (t *Tenant) QuotaUsed() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.quotaDay != today() {
+		return 0
+	}
+	return t.quotaUsed
+}
+
+// QuotaLeft - Reports whether the tenant may start a refresh today
+func (t *Tenant) QuotaLeft() bool {
+	return t.DailyQuota == 0 || t.QuotaUsed() < t.DailyQuota
+}
+
+// Refreshed - Records a finished refresh and the quota units it used
+func (t *Tenant) Refreshed(units int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastRefresh = time.Now()
+	if day := today(); t.quotaDay != day {
+		t.quotaDay, t.quotaUsed = day, 0
+	}
+	t.quotaUsed += units
+}
+
+type contextKey struct{}
+
+// WithTenant - Returns a context that carries the tenant a request is for
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext - Returns the tenant of a request, nil for the main catalog
+func FromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(contextKey{}).(*Tenant)
+	return t
+}